@@ -0,0 +1,51 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+)
+
+// writeArchiveOutput archives the written task at taskPath into dest, which
+// is an archive path or - for stdout. Targets that already write a single
+// file, such as a DOMjudge zip, have the file copied as is.
+func writeArchiveOutput(taskPath string, format string, dest string) error {
+	out := os.Stdout
+	if dest != "-" {
+		var err error
+		out, err = os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	info, err := os.Stat(taskPath)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		err = internal.ArchiveDir(taskPath, format, out)
+	} else {
+		var f *os.File
+		f, err = os.Open(taskPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(out, f)
+	}
+	if err != nil {
+		if dest != "-" {
+			os.Remove(dest)
+		}
+		return err
+	}
+
+	if dest != "-" {
+		return out.Close()
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"github.com/programme-lv/lio-task-importer/internal"
+)
+
+// runDiff compares two versions of a task, each of which may be a source
+// task directory, an archive or a previous import. It exits with status 1
+// if the tasks differ, like diff does.
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	formatA := flags.String("format-a", "auto", "Source format of the first task")
+	formatB := flags.String("format-b", "auto", "Source format of the second task")
+	logFlags := addLogFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s diff [flags] A B\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	err := setupLogging(logFlags, os.Stderr)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
+	a := readTaskForDiff(flags.Arg(0), *formatA)
+	b := readTaskForDiff(flags.Arg(1), *formatB)
+
+	differences := internal.DiffTasks(a, b)
+	if len(differences) == 0 {
+		fmt.Println("Tasks are identical")
+		return
+	}
+	for _, d := range differences {
+		fmt.Println(d)
+	}
+	os.Exit(1)
+}
+
+func readTaskForDiff(sourcePath string, format string) *fstaskparser.Task {
+	taskDir, importer, cleanup, err := internal.OpenTaskSource(sourcePath, format)
+	if err != nil {
+		slog.Error("failed to open task", "source", sourcePath, "error", err)
+		os.Exit(2)
+	}
+	defer cleanup()
+
+	task, warnings, err := importer.Parse(taskDir)
+	if err != nil {
+		slog.Error("failed to parse task", "source", sourcePath, "format", importer.Name(), "error", err)
+		os.Exit(2)
+	}
+	for _, w := range warnings {
+		slog.Warn("import warning", "source", sourcePath, "warning", w)
+	}
+	return task
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+)
+
+// runFormats lists the source formats that can be passed to -format
+// and the target formats that can be passed to -target.
+func runFormats() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Source formats (-format):\n")
+	fmt.Fprintf(w, "  auto\tdetect the format from the source directory\n")
+	for _, imp := range internal.Importers() {
+		fmt.Fprintf(w, "  %s\t%s\n", imp.Name(), imp.Description())
+	}
+	fmt.Fprintf(w, "\nTarget formats (-target):\n")
+	fmt.Fprintf(w, "  proglv\tprogramme.lv task directory with problem.toml\n")
+	for _, exp := range internal.Exporters() {
+		fmt.Fprintf(w, "  %s\t%s\n", exp.Name(), exp.Description())
+	}
+	w.Flush()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+)
+
+// writeHtmlReport writes the jury review report of the LIO 2024 task
+// built of sources to path. The statement link is relative to the report.
+func writeHtmlReport(sources internal.Lio2024Sources, warnings []string, statementHref string, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = internal.Lio2024HtmlReport{
+		Yaml:          sources.Yaml,
+		Tests:         sources.Tests,
+		StatementHref: statementHref,
+		Warnings:      warnings,
+	}.WriteHtml(f)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// htmlReportStatementHref returns the link to the statement PDF for the
+// report at reportPath, or "" if the PDF is not kept anywhere.
+func htmlReportStatementHref(cfg importConfig, taskDir string, outputPath string, reportPath string) (string, error) {
+	if cfg.exporter == nil && cfg.archiveFormat == "" {
+		// the report lies next to the stored task directory
+		return filepath.ToSlash(filepath.Join(filepath.Base(outputPath), "statements", "pdf", "lv.pdf")), nil
+	}
+
+	// an extracted source archive is removed after the import
+	info, err := os.Stat(cfg.source)
+	if err != nil || !info.IsDir() {
+		return "", nil
+	}
+	pdfFiles, err := filepath.Glob(filepath.Join(taskDir, "teksts", "*.pdf"))
+	if err != nil || len(pdfFiles) == 0 {
+		return "", err
+	}
+	absReportDir, err := filepath.Abs(filepath.Dir(reportPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", reportPath, err)
+	}
+	absPdf, err := filepath.Abs(pdfFiles[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", pdfFiles[0], err)
+	}
+	rel, err := filepath.Rel(absReportDir, absPdf)
+	if err != nil {
+		return "", fmt.Errorf("failed to link %s: %w", absPdf, err)
+	}
+	return filepath.ToSlash(rel), nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// logFlags are the flags controlling the log output of a command.
+type logFlags struct {
+	verbose *bool
+	quiet   *bool
+	format  *string
+}
+
+func addLogFlags(flags *flag.FlagSet) logFlags {
+	return logFlags{
+		verbose: flags.Bool("v", false, "Log debug messages, such as how long each stage took"),
+		quiet:   flags.Bool("q", false, "Only log errors"),
+		format:  flags.String("log-format", "text", "Format of the log messages: text or json"),
+	}
+}
+
+// newLogger returns the logger the flags ask for, writing to w.
+func (f logFlags) newLogger(w io.Writer) (*slog.Logger, error) {
+	if *f.verbose && *f.quiet {
+		return nil, fmt.Errorf("-v and -q cannot be used together")
+	}
+
+	opts := &slog.HandlerOptions{Level: slog.LevelInfo}
+	if *f.verbose {
+		opts.Level = slog.LevelDebug
+	}
+	if *f.quiet {
+		opts.Level = slog.LevelError
+	}
+
+	switch *f.format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unsupported log format %q, supported formats: text, json", *f.format)
+}
+
+// setupLogging makes the logger the flags ask for the default one, so that
+// the messages of libraries using the log package go through it as well.
+// fstaskparser narrates every file it writes, so those messages are only
+// shown with -v.
+func setupLogging(f logFlags, w io.Writer) error {
+	logger, err := f.newLogger(w)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	slog.SetLogLoggerLevel(slog.LevelDebug)
+	return nil
+}
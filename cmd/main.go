@@ -1,49 +1,542 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
 	"github.com/programme-lv/lio-task-importer/internal"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "formats":
+			runFormats()
+			return
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		case "validate":
+			runValidate(os.Args[2:])
+			return
+		}
+	}
+
+	runImport()
+}
+
+// importConfig holds the flags of an import run.
+type importConfig struct {
+	source        string
+	format        string
+	dest          string
+	streamFormat  string
+	target        string
+	origin        internal.Origin
+	tagVocabPath  string
+	update        bool
+	linkTests     bool
+	dryRun        bool
+	jsonOutput    bool
+	htmlReport    bool
+	parseOptions  internal.ParseOptions
+	exporter      internal.Exporter
+	archiveFormat string
+}
+
+func runImport() {
 	// Define flags
-	sourceDir := flag.String("source", "", "Source directory containing the tasks")
-	sourceFormat := flag.String("format", "lio2024", "Source format of the tasks")
-	destDir := flag.String("dest", "", "Destination directory where the new directory will be placed")
+	sourceDir := flag.String("source", "", "Source directory containing the task, or a .zip/.tar.gz archive of it; more sources may follow the flags")
+	sourceFormat := flag.String("format", "lio2024", "Source format of the tasks, see the formats command, or auto to detect it")
+	destDir := flag.String("dest", "", "Destination directory where the new directory will be placed, an archive path (.zip, .tar.gz) or - for stdout")
+	streamFormat := flag.String("archive-format", internal.ArchiveZip, "Archive format written when -dest is -: zip or tar.gz")
+	targetFormat := flag.String("target", "proglv", "Format to write the task in, see the formats command")
+	olympiad := flag.String("olympiad", "", "Olympiad the task originates from (default: from olympiad.yaml, path or LIO)")
+	originYear := flag.Int("origin-year", 0, "Year of the olympiad the task was used in")
+	originStage := flag.String("origin-stage", "", "Stage of the olympiad: school, regional, national or selection")
+	originNote := flag.String("origin-note", "", "Free-form note about the origin of the task")
+	tagVocabPath := flag.String("tag-vocabulary", "", "File listing allowed task tags, one per line")
+	update := flag.Bool("update", false, "Update an existing programme.lv task directory in place, rewriting only what changed")
+	linkTests := flag.Bool("link-duplicate-tests", false, "Store identical test files once, as hard links to each other (proglv target only)")
+	dryRun := flag.Bool("dry-run", false, "Parse the task and print what would be written instead of writing it")
+	jsonOutput := flag.Bool("json", false, "Print the -dry-run plan as JSON")
+	reportPath := flag.String("report", "", "Write a JSON report of the run to this file, - for stdout")
+	htmlReport := flag.Bool("html-report", false, "Write an HTML report for jury review next to the output (lio2024 sources only)")
+	normalizeTests := flag.String("normalize-tests", internal.NormalizeOff,
+		"Normalise line endings and encoding of LIO tests: off, fix, or strict to only report what fix would change")
+	generateTests := flag.Bool("generate-tests", false,
+		"Make the tests by running the generator plan and solution named in task.yaml, comparing them with the test archive if there is one (lio2024 sources only)")
+	logFlags := addLogFlags(flag.CommandLine)
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [SOURCE...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 
 	// Parse flags
 	flag.Parse()
 
+	err := setupLogging(logFlags, os.Stderr)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// sources after the flags are imported in a batch with the -source one
+	sources := flag.Args()
+	if *sourceDir != "" {
+		sources = append([]string{*sourceDir}, sources...)
+	}
+
 	// Validate flags
-	if *sourceDir == "" || *destDir == "" {
+	if len(sources) == 0 || (*destDir == "" && !*dryRun) {
 		fmt.Println("Source and destination directories must be specified.")
 		flag.Usage()
 		os.Exit(1)
 	}
+	if *jsonOutput && !*dryRun {
+		fmt.Println("-json only works with -dry-run.")
+		os.Exit(1)
+	}
+	if *reportPath == "-" && (*destDir == "-" || *dryRun || *update) {
+		fmt.Println("-report - cannot be combined with -dest -, -dry-run or -update, which also write to stdout.")
+		os.Exit(1)
+	}
+	if len(sources) > 1 && (*destDir == "-" || internal.ArchiveFormatFromPath(*destDir) != "") {
+		fmt.Println("Several sources can only be imported into a destination directory.")
+		os.Exit(1)
+	}
+	if *htmlReport && *destDir == "-" {
+		fmt.Println("-html-report needs a destination to write the report next to.")
+		os.Exit(1)
+	}
+	if *originStage != "" && !slices.Contains(internal.OriginStages, *originStage) {
+		fmt.Printf("-origin-stage must be one of: %s\n", strings.Join(internal.OriginStages, ", "))
+		os.Exit(1)
+	}
+	if !slices.Contains(internal.ArchiveFormats, *streamFormat) {
+		fmt.Printf("-archive-format must be one of: %s\n", strings.Join(internal.ArchiveFormats, ", "))
+		os.Exit(1)
+	}
+	if !slices.Contains(internal.NormalizeModes, *normalizeTests) {
+		fmt.Printf("-normalize-tests must be one of: %s\n", strings.Join(internal.NormalizeModes, ", "))
+		os.Exit(1)
+	}
+
+	cfg := importConfig{
+		format:       *sourceFormat,
+		dest:         *destDir,
+		streamFormat: *streamFormat,
+		target:       *targetFormat,
+		origin: internal.Origin{
+			Olympiad: *olympiad,
+			Year:     *originYear,
+			Stage:    *originStage,
+			Notes:    *originNote,
+		},
+		tagVocabPath: *tagVocabPath,
+		update:       *update,
+		linkTests:    *linkTests,
+		dryRun:       *dryRun,
+		jsonOutput:   *jsonOutput,
+		htmlReport:   *htmlReport,
+		parseOptions: internal.ParseOptions{NormalizeTests: *normalizeTests, GenerateTests: *generateTests},
+	}
+
+	if *targetFormat != "proglv" {
+		var err error
+		cfg.exporter, err = internal.GetExporter(*targetFormat)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
 
-	// Check if the source format is "lio2024"
-	if *sourceFormat != "lio2024" {
-		fmt.Println("Unsupported source format. Only 'lio2024' is supported.")
+	if *update && (cfg.exporter != nil || internal.ArchiveFormatFromPath(*destDir) != "" || *destDir == "-") {
+		fmt.Println("-update only works with the proglv target and a destination directory.")
+		os.Exit(1)
+	}
+	if *linkTests && (cfg.exporter != nil || internal.ArchiveFormatFromPath(*destDir) != "" || *destDir == "-" || *update) {
+		fmt.Println("-link-duplicate-tests only works with the proglv target and a new destination directory.")
 		os.Exit(1)
 	}
 
-	// Get the base name of the source directory
-	baseName := filepath.Base(*sourceDir)
-	newDirName := baseName + "_proglv"
-	newDirPath := filepath.Join(*destDir, newDirName)
+	// -dest may name an archive, then the task is written to a temporary
+	// directory first and archived from there
+	cfg.archiveFormat = internal.ArchiveFormatFromPath(*destDir)
+	if *destDir == "-" {
+		cfg.archiveFormat = *streamFormat
+	}
+	if cfg.archiveFormat != "" && cfg.exporter != nil && cfg.exporter.Extension() != "" &&
+		internal.ArchiveFormatFromPath(cfg.exporter.Extension()) != cfg.archiveFormat {
+		fmt.Printf("Target %s writes a %s file, it cannot be written as %s\n",
+			cfg.exporter.Name(), cfg.exporter.Extension(), cfg.archiveFormat)
+		os.Exit(1)
+	}
 
-	task, err := internal.ParseLio2024TaskDir(*sourceDir)
+	// an interrupt stops the import between files instead of leaving
+	// half-written output behind
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	report := internal.NewImportReport()
+	failed := 0
+	for _, source := range sources {
+		if ctx.Err() != nil {
+			break
+		}
+		cfg.source = source
+		taskReport := runImportTask(ctx, cfg)
+		report.Tasks = append(report.Tasks, taskReport)
+		if taskReport.Status == internal.StatusFailed {
+			failed++
+		}
+	}
+	stop()
+
+	if *reportPath != "" {
+		err := writeReport(report, *reportPath)
+		if err != nil {
+			slog.Error("failed to write report", "path", *reportPath, "error", err)
+		}
+	}
+
+	if len(sources) > 1 {
+		slog.Info("imported tasks", "tasks", len(report.Tasks), "failed", failed, "skipped", len(sources)-len(report.Tasks))
+	}
+	if failed > 0 || len(report.Tasks) < len(sources) {
+		os.Exit(1)
+	}
+}
+
+// runImportTask imports the task of cfg.source and reports how it went.
+// A failed import is logged, later tasks of a batch are still imported.
+func runImportTask(ctx context.Context, cfg importConfig) internal.TaskReport {
+	start := time.Now()
+	taskReport := internal.TaskReport{
+		Source:   cfg.source,
+		Target:   cfg.target,
+		Status:   internal.StatusOK,
+		Warnings: []string{},
+		Stages:   []internal.StageTiming{},
+	}
+	importErr := importTask(ctx, cfg, &taskReport)
+	taskReport.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	if importErr != nil {
+		taskReport.Status = internal.StatusFailed
+		taskReport.Output = ""
+		taskReport.Errors = internal.ErrorChain(importErr)
+		taskReport.ErrorCode = internal.ErrorCodeOf(importErr)
+
+		attrs := []any{"source", cfg.source, "error", importErr}
+		if taskReport.ErrorCode != "" {
+			attrs = append(attrs, "error_code", taskReport.ErrorCode)
+		}
+		slog.Error("failed to import task", attrs...)
+	}
+	return taskReport
+}
+
+// importTask imports the task as configured, filling in the report as
+// it goes.
+func importTask(ctx context.Context, cfg importConfig, report *internal.TaskReport) (importErr error) {
+	timings := &internal.StageTimings{Logger: slog.Default()}
+	defer func() {
+		report.Stages = append(report.Stages, timings.Stages...)
+	}()
+
+	// -q hides the progress along with the info messages
+	if isTerminal(os.Stderr) && slog.Default().Enabled(ctx, slog.LevelInfo) {
+		bar := newProgressBar(os.Stderr)
+		timings.Progress = bar.Update
+		defer bar.Finish()
+	}
+
+	outDir := cfg.dest
+	if cfg.archiveFormat != "" {
+		if _, err := os.Stat(cfg.dest); cfg.dest != "-" && !os.IsNotExist(err) {
+			return fmt.Errorf("file already exists: %s", cfg.dest)
+		}
+
+		tmpDir, err := os.MkdirTemp("", "lio-task-importer")
+		if err != nil {
+			return fmt.Errorf("failed to create tmp directory: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		outDir = tmpDir
+	}
+
+	baseName := internal.TaskShortName(cfg.source)
+	newDirName := baseName + "_" + cfg.target
+	newDirPath := filepath.Join(outDir, newDirName)
+
+	done := timings.Start("detect")
+	taskDir, importer, cleanup, err := internal.OpenTaskSource(cfg.source, cfg.format)
+	done()
 	if err != nil {
-		log.Fatalf("Failed to parse Lio2024 task: %v\n", err)
+		return err
+	}
+	defer cleanup()
+	report.Format = importer.Name()
+	if cfg.htmlReport && importer.Name() != "lio2024" {
+		return fmt.Errorf("-html-report only works with lio2024 sources, not %s", importer.Name())
 	}
 
-	err = task.Store(newDirPath)
+	var task *fstaskparser.Task
+	var warnings []string
+	fsImporter, ok := importer.(internal.FSImporter)
+	if !slices.Contains(internal.LioFormats, importer.Name()) && cfg.parseOptions.NormalizeTests != internal.NormalizeOff {
+		return fmt.Errorf("-normalize-tests only works with LIO sources, not %s", importer.Name())
+	}
+	if cfg.parseOptions.GenerateTests && importer.Name() != "lio2024" {
+		return fmt.Errorf("-generate-tests only works with lio2024 sources, not %s", importer.Name())
+	}
+	// the report shows the tests as imported, not as they are in the source
+	var reportSources internal.Lio2024Sources
+	if cfg.htmlReport {
+		task, reportSources, warnings, err = internal.ParseLio2024TaskWithSources(ctx, os.DirFS(taskDir), cfg.parseOptions, timings)
+	} else if ok {
+		task, warnings, err = fsImporter.ParseFS(ctx, os.DirFS(taskDir), filepath.Base(taskDir), cfg.parseOptions, timings)
+	} else {
+		done = timings.Start("parse")
+		task, warnings, err = importer.Parse(taskDir)
+		done()
+	}
 	if err != nil {
-		log.Fatalf("Failed to store task: %v\n", err)
+		return fmt.Errorf("failed to parse %s task: %w", importer.Name(), err)
+	}
+	for _, w := range warnings {
+		slog.Warn("import warning", "warning", w)
 	}
+	report.Warnings = append(report.Warnings, warnings...)
+	report.Examples = len(task.GetExamples())
+	for _, t := range task.GetTestsSortedByID() {
+		report.Tests++
+		report.TestBytes += int64(len(t.Input) + len(t.Answer))
+	}
+
+	if cfg.tagVocabPath != "" {
+		vocabulary, err := internal.ReadTagVocabulary(cfg.tagVocabPath)
+		if err != nil {
+			return err
+		}
+		err = internal.TaskMetadata{Tags: task.GetProblemTags()}.Validate(vocabulary)
+		if err != nil {
+			return fmt.Errorf("invalid task tags: %w", err)
+		}
+	}
+
+	// flags take precedence over the config file, which takes precedence over the path
+	origin, err := internal.ResolveOrigin(cfg.origin, cfg.source, task)
+	if err != nil {
+		return err
+	}
+
+	task.SetOriginOlympiad(origin.Olympiad)
+
+	var checker []internal.SourceFile
+	var checkerKind string
+	if finder, ok := importer.(internal.CheckerFinder); ok {
+		checker, err = finder.FindChecker(os.DirFS(taskDir))
+		if err != nil {
+			return fmt.Errorf("failed to read checker: %w", err)
+		}
+		checkerKind = finder.CheckerKind()
+	}
+
+	var validator []internal.SourceFile
+	if finder, ok := importer.(internal.ValidatorFinder); ok {
+		validator, err = finder.FindValidator(os.DirFS(taskDir))
+		if err != nil {
+			return fmt.Errorf("failed to read validator: %w", err)
+		}
+	}
+
+	var graders []internal.SourceFile
+	if finder, ok := importer.(internal.GraderFinder); ok {
+		graders, err = finder.FindGraders(os.DirFS(taskDir))
+		if err != nil {
+			return fmt.Errorf("failed to read graders: %w", err)
+		}
+	}
+
+	outputPath := newDirPath
+	if cfg.exporter != nil {
+		outputPath += cfg.exporter.Extension()
+	}
+
+	// fail before the plan is printed, a dry run must not promise an import
+	// that cannot be done
+	if cfg.exporter == nil && checker != nil {
+		// TODO: implement once the programme.lv task format supports checkers
+		return fmt.Errorf("failed to store task: checkers are not implemented yet (found %s)", checker[0].Filename)
+	}
+
+	if cfg.dryRun {
+		plan := internal.NewImportPlan(task)
+		plan.Source = cfg.source
+		plan.Format = importer.Name()
+		plan.Target = cfg.target
+		if cfg.archiveFormat != "" {
+			plan.Output = cfg.dest
+		} else if cfg.dest != "" {
+			plan.Output = outputPath
+		}
+		plan.Origin = origin
+		plan.Warnings = append(plan.Warnings, warnings...)
+		if checker != nil {
+			plan.Checker = checker[0].Filename
+		}
+		if validator != nil {
+			plan.Validator = validator[0].Filename
+		}
+		for _, f := range graders {
+			plan.Graders = append(plan.Graders, f.Filename)
+		}
+		if lister, ok := importer.(internal.UsedFilesLister); ok {
+			used, err := lister.UsedFiles(os.DirFS(taskDir))
+			if err != nil {
+				return fmt.Errorf("failed to list used files: %w", err)
+			}
+			plan.IgnoredFiles, err = internal.IgnoredFiles(taskDir, used)
+			if err != nil {
+				return fmt.Errorf("failed to list ignored files: %w", err)
+			}
+		}
+
+		err = printPlan(plan, cfg.jsonOutput)
+		if err != nil {
+			return fmt.Errorf("failed to print plan: %w", err)
+		}
+		return nil
+	}
+
+	// the report is written first, so that a failed one leaves no output
+	// behind
+	if cfg.htmlReport {
+		reportPath := newDirPath + "_report.html"
+		if cfg.archiveFormat != "" {
+			reportPath = internal.TrimArchiveExtension(cfg.dest) + "_report.html"
+		}
+		href, err := htmlReportStatementHref(cfg, taskDir, outputPath, reportPath)
+		if err != nil {
+			return fmt.Errorf("failed to link statement: %w", err)
+		}
+		err = writeHtmlReport(reportSources, warnings, href, reportPath)
+		if err != nil {
+			return fmt.Errorf("failed to write HTML report: %w", err)
+		}
+		defer func() {
+			if importErr != nil {
+				os.Remove(reportPath)
+			}
+		}()
+	}
+
+	if cfg.exporter != nil {
+		opts := internal.ExportOptions{
+			ShortName:   baseName,
+			Checker:     checker,
+			CheckerKind: checkerKind,
+			Validator:   validator,
+			Graders:     graders,
+		}
+		done = timings.Start("export")
+		err = cfg.exporter.Export(task, outputPath, opts)
+		done()
+		if err != nil {
+			return fmt.Errorf("failed to export task to %s: %w", cfg.exporter.Name(), err)
+		}
+	} else {
+		// an update stores the task next to the existing one first
+		storePath := newDirPath
+		_, err = os.Stat(newDirPath)
+		updating := cfg.update && err == nil
+		if updating {
+			tmpDir, err := os.MkdirTemp("", "lio-task-update")
+			if err != nil {
+				return fmt.Errorf("failed to create tmp directory: %w", err)
+			}
+			defer os.RemoveAll(tmpDir)
+			storePath = filepath.Join(tmpDir, newDirName)
+		}
+
+		done = timings.Start("store")
+		err = task.Store(storePath)
+		if err != nil {
+			return fmt.Errorf("failed to store task: %w", err)
+		}
+
+		err = internal.WriteOriginToProblemToml(storePath, origin)
+		if err != nil {
+			return fmt.Errorf("failed to store task origin: %w", err)
+		}
+
+		if validator != nil {
+			err = internal.StoreValidator(storePath, validator)
+			if err != nil {
+				return fmt.Errorf("failed to store validator: %w", err)
+			}
+		}
+
+		if graders != nil {
+			err = internal.StoreGraders(storePath, graders)
+			if err != nil {
+				return fmt.Errorf("failed to store graders: %w", err)
+			}
+		}
+
+		if cfg.linkTests {
+			linked, saved, err := internal.LinkDuplicateTestFiles(storePath)
+			if err != nil {
+				return fmt.Errorf("failed to link duplicate tests: %w", err)
+			}
+			slog.Info("linked duplicate test files", "files", linked, "bytes_saved", saved)
+		}
+
+		if updating {
+			changes, err := internal.UpdateTaskDir(storePath, newDirPath)
+			if err != nil {
+				return fmt.Errorf("failed to update %s: %w", newDirPath, err)
+			}
+			if len(changes) == 0 {
+				fmt.Printf("%s is up to date\n", newDirPath)
+			}
+			for _, c := range changes {
+				fmt.Printf("%-8s %s\n", c.Kind, c.Path)
+			}
+		}
+		done()
+	}
+
+	report.Output = outputPath
+	report.OutputBytes, err = internal.PathSize(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to measure output: %w", err)
+	}
+
+	if cfg.archiveFormat != "" {
+		done = timings.Start("archive")
+		err = writeArchiveOutput(outputPath, cfg.archiveFormat, cfg.dest)
+		done()
+		if err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+		report.Output = cfg.dest
+		if cfg.dest != "-" {
+			report.OutputBytes, err = internal.PathSize(cfg.dest)
+			if err != nil {
+				return fmt.Errorf("failed to measure output: %w", err)
+			}
+		}
+	}
+
+	return nil
 }
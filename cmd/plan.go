@@ -0,0 +1,18 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+)
+
+// printPlan writes the plan of a -dry-run to stdout.
+func printPlan(plan internal.ImportPlan, asJSON bool) error {
+	if !asJSON {
+		return plan.WriteText(os.Stdout)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(plan)
+}
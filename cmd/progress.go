@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+)
+
+// progressBarWidth is the number of cells between the brackets.
+const progressBarWidth = 30
+
+// progressRedrawInterval limits how often the bar is redrawn while tests
+// are read.
+const progressRedrawInterval = 100 * time.Millisecond
+
+// progressBar draws the progress of an import on a single terminal line.
+type progressBar struct {
+	w        io.Writer
+	stage    string
+	event    internal.ProgressEvent
+	drawn    bool
+	lastDraw time.Time
+}
+
+func newProgressBar(w io.Writer) *progressBar {
+	return &progressBar{w: w}
+}
+
+// isTerminal tells whether the file is a terminal rather than a pipe or
+// a regular file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Update takes in a progress event, see internal.StageTimings.Progress.
+func (b *progressBar) Update(event internal.ProgressEvent) {
+	switch event.Kind {
+	case internal.ProgressStageStarted:
+		b.stage = event.Stage
+		b.event = internal.ProgressEvent{}
+		b.draw()
+	case internal.ProgressTestsRead:
+		b.stage = event.Stage
+		b.event = event
+		if event.TestsDone == event.TestsTotal || time.Since(b.lastDraw) >= progressRedrawInterval {
+			b.draw()
+		}
+	case internal.ProgressStageFinished:
+		// messages logged between stages then start on a clean line
+		b.Finish()
+	}
+}
+
+func (b *progressBar) draw() {
+	line := b.stage
+	if b.event.TestsTotal > 0 {
+		filled := progressBarWidth * b.event.TestsDone / b.event.TestsTotal
+		bar := strings.Repeat("=", filled)
+		if filled < progressBarWidth {
+			bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+		}
+		line = fmt.Sprintf("[%s] %d/%d tests  %.1f MB  %s", bar,
+			b.event.TestsDone, b.event.TestsTotal, float64(b.event.Bytes)/(1<<20), b.stage)
+	}
+	fmt.Fprintf(b.w, "\r\033[K%s", line)
+	b.drawn = true
+	b.lastDraw = time.Now()
+}
+
+// Finish clears the bar so that later output starts on a clean line.
+func (b *progressBar) Finish() {
+	if b.drawn {
+		fmt.Fprint(b.w, "\r\033[K")
+		b.drawn = false
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+)
+
+// writeReport writes the JSON report to path, or to stdout if it is -.
+func writeReport(report *internal.ImportReport, path string) error {
+	if path == "-" {
+		return report.Write(os.Stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = report.Write(f)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
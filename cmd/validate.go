@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+)
+
+// runValidate compiles the input validator of a task and runs it on the
+// input of every test. It exits with status 1 if the validator rejects
+// any test, like diff does when the tasks differ.
+func runValidate(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	format := flags.String("format", "auto", "Source format of the task")
+	normalizeTests := flags.String("normalize-tests", internal.NormalizeOff,
+		"Normalise the tests as an import with the same flag would before validating them: off or fix")
+	generateTests := flags.Bool("generate-tests", false,
+		"Validate the tests made by running the generator plan and solution named in task.yaml instead of the test archive")
+	logFlags := addLogFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s validate [flags] SOURCE\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	err := setupLogging(logFlags, os.Stderr)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	if !slices.Contains(internal.NormalizeModes, *normalizeTests) {
+		fmt.Printf("-normalize-tests must be one of: %s\n", strings.Join(internal.NormalizeModes, ", "))
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	opts := internal.ParseOptions{NormalizeTests: *normalizeTests, GenerateTests: *generateTests}
+	failures, err := validateTask(ctx, flags.Arg(0), *format, opts)
+	stop()
+	if err != nil {
+		slog.Error("failed to validate task", "source", flags.Arg(0), "error", err)
+		os.Exit(2)
+	}
+
+	if len(failures) == 0 {
+		fmt.Println("All tests are valid")
+		return
+	}
+	for _, f := range failures {
+		fmt.Println(f)
+	}
+	os.Exit(1)
+}
+
+func validateTask(ctx context.Context, sourcePath string, format string, opts internal.ParseOptions) ([]internal.ValidatorFailure, error) {
+	timings := &internal.StageTimings{Logger: slog.Default()}
+
+	taskDir, importer, cleanup, err := internal.OpenTaskSource(sourcePath, format)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	if importer.Name() != "lio2024" {
+		return nil, fmt.Errorf("validators only work with lio2024 sources, not %s", importer.Name())
+	}
+
+	fsys := os.DirFS(taskDir)
+	validatorFiles, err := importer.(internal.ValidatorFinder).FindValidator(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validator: %w", err)
+	}
+	if validatorFiles == nil {
+		return nil, fmt.Errorf("task.yaml names no validator")
+	}
+
+	// the tests are read as the importer reads them, generated ones too
+	sources, warnings, err := internal.ImportLio2024Sources(ctx, fsys, opts, timings)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range warnings {
+		slog.Warn("import warning", "warning", w)
+	}
+
+	subtasks := map[int]int{}
+	for _, g := range sources.Yaml.TestGroups {
+		subtasks[g.GroupID] = g.Subtask
+	}
+
+	done := timings.Start("compile validator")
+	validator, err := internal.CompileValidator(ctx, validatorFiles)
+	done()
+	if err != nil {
+		return nil, err
+	}
+	defer validator.Close()
+
+	return internal.ValidateLioTests(ctx, validator, sources.Tests, subtasks, timings)
+}
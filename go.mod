@@ -3,6 +3,7 @@ module github.com/programme-lv/lio-task-importer
 go 1.22.4
 
 require (
+	github.com/pelletier/go-toml/v2 v2.2.2
 	github.com/programme-lv/fs-task-format-parser v0.0.0-20240726203536-1f5027d1d3cd
 	github.com/stretchr/testify v1.9.0
 	gopkg.in/yaml.v2 v2.4.0
@@ -10,7 +11,6 @@ require (
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/pelletier/go-toml/v2 v2.2.2 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
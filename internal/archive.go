@@ -0,0 +1,204 @@
+package internal
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Archive formats that a task can be written as.
+const (
+	ArchiveZip   = "zip"
+	ArchiveTarGz = "tar.gz"
+)
+
+// ArchiveFormats lists the archive formats a task can be written as.
+var ArchiveFormats = []string{ArchiveZip, ArchiveTarGz}
+
+// ArchiveFormatFromPath returns the archive format implied by the extension
+// of the path, or "" if the path is not an archive.
+func ArchiveFormatFromPath(path string) string {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGz
+	}
+	return ""
+}
+
+// TrimArchiveExtension removes the archive extension from the path.
+func TrimArchiveExtension(path string) string {
+	lower := strings.ToLower(path)
+	for _, ext := range []string{".zip", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(lower, ext) {
+			return path[:len(path)-len(ext)]
+		}
+	}
+	return path
+}
+
+// ExtractArchive extracts a zip or gzip-compressed tar archive, chosen by
+// the extension of src, to the destination directory.
+func ExtractArchive(src, dest string) error {
+	switch ArchiveFormatFromPath(src) {
+	case ArchiveZip:
+		return Unzip(src, dest)
+	case ArchiveTarGz:
+		return Untar(src, dest)
+	}
+	return fmt.Errorf("unsupported archive %s, supported extensions: .zip, .tar.gz, .tgz", filepath.Base(src))
+}
+
+// Untar extracts a gzip-compressed tar archive to a specified destination.
+// Only regular files and directories are extracted, links are refused.
+func Untar(src, dest string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fpath, err := archiveEntryPath(dest, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(fpath, os.ModePerm)
+			if err != nil {
+				return err
+			}
+			continue
+		case tar.TypeReg:
+		case tar.TypeXGlobalHeader:
+			continue
+		default:
+			return fmt.Errorf("unsupported entry type of %s", header.Name)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+			return err
+		}
+
+		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, header.FileInfo().Mode().Perm())
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(outFile, tr)
+
+		outFile.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ExtractTaskArchive extracts an archived task to the destination and
+// returns the task directory. Archives often wrap the task in a single
+// top-level directory, which is then the task directory.
+func ExtractTaskArchive(archivePath, dest string) (string, error) {
+	err := ExtractArchive(archivePath, dest)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", filepath.Base(archivePath), err)
+	}
+
+	entries, err := os.ReadDir(dest)
+	if err != nil {
+		return "", fmt.Errorf("failed to read directory %s: %w", dest, err)
+	}
+	// macOS adds resource forks next to the task when zipping
+	contents := []os.DirEntry{}
+	for _, entry := range entries {
+		if entry.Name() != "__MACOSX" {
+			contents = append(contents, entry)
+		}
+	}
+	if len(contents) == 1 && contents[0].IsDir() {
+		return filepath.Join(dest, contents[0].Name()), nil
+	}
+	return dest, nil
+}
+
+// ArchiveDir writes the files of a directory into an archive of the given
+// format. The same directory content always produces the same bytes.
+func ArchiveDir(srcDir string, format string, w io.Writer) error {
+	switch format {
+	case ArchiveZip:
+		return ZipDir(srcDir, w)
+	case ArchiveTarGz:
+		return TarGzDir(srcDir, w)
+	}
+	return fmt.Errorf("unsupported archive format %q, supported formats: %s",
+		format, strings.Join(ArchiveFormats, ", "))
+}
+
+// TarGzDir writes the files of a directory into a gzip-compressed tar
+// archive. Entries are written in lexicographical order with a fixed
+// modification time and owner.
+func TarGzDir(srcDir string, w io.Writer) error {
+	paths, err := listFilesSorted(srcDir)
+	if err != nil {
+		return err
+	}
+
+	// the gzip header is left without a name or modification time
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, relPath := range paths {
+		content, err := os.ReadFile(filepath.Join(srcDir, filepath.FromSlash(relPath)))
+		if err != nil {
+			return err
+		}
+		mode, err := archiveFileMode(filepath.Join(srcDir, filepath.FromSlash(relPath)))
+		if err != nil {
+			return err
+		}
+
+		err = tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     relPath,
+			Size:     int64(len(content)),
+			Mode:     int64(mode),
+			ModTime:  archiveModTime,
+			Format:   tar.FormatPAX,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(content)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	return gw.Close()
+}
@@ -0,0 +1,118 @@
+package internal_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveDirIsDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "tests"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "problem.toml"), []byte("name = 'kp'\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tests", "001.in"), []byte("1\n"), 0644))
+
+	for _, format := range []string{internal.ArchiveZip, internal.ArchiveTarGz} {
+		first := bytes.Buffer{}
+		require.NoError(t, internal.ArchiveDir(dir, format, &first))
+
+		// touching the files must not change the archive
+		modTime := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "problem.toml"), modTime, modTime))
+		second := bytes.Buffer{}
+		require.NoError(t, internal.ArchiveDir(dir, format, &second))
+
+		assert.Equal(t, first.Bytes(), second.Bytes(), format)
+	}
+
+	archive := bytes.Buffer{}
+	require.NoError(t, internal.ArchiveDir(dir, internal.ArchiveTarGz, &archive))
+	gr, err := gzip.NewReader(&archive)
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+	names := []string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+	}
+	assert.Equal(t, []string{"problem.toml", "tests/001.in"}, names)
+
+	assert.Error(t, internal.ArchiveDir(dir, "rar", &archive))
+}
+
+func TestArchiveFormatFromPath(t *testing.T) {
+	assert.Equal(t, internal.ArchiveZip, internal.ArchiveFormatFromPath("out/kp.ZIP"))
+	assert.Equal(t, internal.ArchiveTarGz, internal.ArchiveFormatFromPath("kp.tar.gz"))
+	assert.Equal(t, internal.ArchiveTarGz, internal.ArchiveFormatFromPath("kp.tgz"))
+	assert.Equal(t, "", internal.ArchiveFormatFromPath("out"))
+}
+
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	buf := bytes.Buffer{}
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg, Name: name, Size: int64(len(files[name])), Mode: 0644,
+		}))
+		_, err := tw.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+}
+
+func TestExtractTaskArchive(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "kp.tar.gz")
+	writeTarGz(t, archivePath, map[string]string{
+		"kp/task.yaml":   "name: kp\n",
+		"kp/teksts/a.md": "#\n",
+	})
+
+	dest := t.TempDir()
+	taskDir, err := internal.ExtractTaskArchive(archivePath, dest)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dest, "kp"), taskDir)
+	content, err := os.ReadFile(filepath.Join(taskDir, "task.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name: kp\n", string(content))
+
+	evilPath := filepath.Join(t.TempDir(), "evil.tgz")
+	writeTarGz(t, evilPath, map[string]string{"../evil.txt": "x"})
+	_, err = internal.ExtractTaskArchive(evilPath, t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestReadLioTestsFromTarGz(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "tests.tgz")
+	writeTarGz(t, archivePath, map[string]string{
+		"kp.i00": "1\n", "kp.o00": "2\n",
+		"kp.i01a": "3\n", "kp.o01a": "4\n",
+	})
+
+	tests, err := internal.ReadLioTestsFromArchive(archivePath)
+	require.NoError(t, err)
+	require.Len(t, tests, 2)
+	assert.Equal(t, 1, tests[1].TestGroup)
+	assert.Equal(t, "4\n", string(tests[1].Answer))
+}
@@ -0,0 +1,461 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	RegisterImporter(cmsImporter{})
+}
+
+type cmsImporter struct{}
+
+func (cmsImporter) Name() string { return "cms" }
+
+func (cmsImporter) Description() string {
+	return "CMS italy_yaml task directory with task.yaml, gen/GEN, input/ and output/"
+}
+
+func (cmsImporter) Detect(fsys fs.FS) bool {
+	if _, err := fs.Stat(fsys, "task.yaml"); err != nil {
+		return false
+	}
+	if _, err := fs.Stat(fsys, "input"); err == nil {
+		return true
+	}
+	_, err := fs.Stat(fsys, "gen/GEN")
+	return err == nil
+}
+
+func (cmsImporter) Parse(dirPath string) (*fstaskparser.Task, []string, error) {
+	return ParseCmsTaskDir(dirPath)
+}
+
+func (cmsImporter) ParseFS(ctx context.Context, fsys fs.FS, _ string, _ ParseOptions, timings *StageTimings) (*fstaskparser.Task, []string, error) {
+	return parseCmsTaskFS(ctx, fsys, timings)
+}
+
+// cmsCheckerDirs are the directories a CMS task keeps its checker in,
+// check/ in newer tasks and cor/ in older ones.
+var cmsCheckerDirs = []string{"check", "cor"}
+
+// FindChecker returns the source in check/ or cor/. A directory holding
+// several sources must name the checker checker.* or correttore.*.
+func (cmsImporter) FindChecker(fsys fs.FS) ([]SourceFile, error) {
+	for _, checkerDir := range cmsCheckerDirs {
+		entries, err := fs.ReadDir(fsys, checkerDir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s/: %w", checkerDir, err)
+		}
+
+		sources := []string{}
+		named := []string{}
+		for _, entry := range entries {
+			ext := path.Ext(entry.Name())
+			if entry.IsDir() || !programSourceExts[ext] {
+				continue
+			}
+			sources = append(sources, entry.Name())
+			if base := strings.TrimSuffix(entry.Name(), ext); base == "checker" || base == "correttore" {
+				named = append(named, entry.Name())
+			}
+		}
+		if len(sources) > 1 && len(named) == 1 {
+			sources = named
+		}
+		switch {
+		case len(sources) == 0 && len(entries) > 0:
+			return nil, fmt.Errorf("%s/ holds no checker source", checkerDir)
+		case len(sources) == 0:
+			continue
+		case len(sources) > 1:
+			return nil, fmt.Errorf("%s/ holds more than one checker source: %s", checkerDir, strings.Join(sources, ", "))
+		}
+		return readSourceFileWithHeaders(fsys, path.Join(checkerDir, sources[0]))
+	}
+	return nil, nil
+}
+
+func (cmsImporter) CheckerKind() string { return CheckerCms }
+
+// FindGraders returns the sol/grader.* sources followed by the headers in
+// sol/, which the graders and the solutions share.
+func (cmsImporter) FindGraders(fsys fs.FS) ([]SourceFile, error) {
+	graders, err := fs.Glob(fsys, "sol/grader.*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find graders: %w", err)
+	}
+	sort.Strings(graders)
+
+	res := []SourceFile{}
+	for _, grader := range graders {
+		if !programSourceExts[path.Ext(grader)] {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, grader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path.Base(grader), err)
+		}
+		res = append(res, SourceFile{Filename: path.Base(grader), Content: content})
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	headers, err := fs.Glob(fsys, "sol/*.h")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find headers: %w", err)
+	}
+	sort.Strings(headers)
+	for _, header := range headers {
+		content, err := fs.ReadFile(fsys, header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path.Base(header), err)
+		}
+		res = append(res, SourceFile{Filename: path.Base(header), Content: content})
+	}
+
+	return res, nil
+}
+
+type ParsedCmsYaml struct {
+	ShortName              string
+	Title                  string
+	CpuTimeLimitInSeconds  float64
+	MemoryLimitInMegabytes int
+	NumberOfInputs         int
+	// PublicTestcases is nil if all testcases are public.
+	PublicTestcases map[int]bool
+}
+
+type cmsRawYaml struct {
+	Name            string      `yaml:"name"`
+	Title           string      `yaml:"title"`
+	TimeLimit       float64     `yaml:"time_limit"`
+	MemoryLimit     int         `yaml:"memory_limit"`
+	NInput          int         `yaml:"n_input"`
+	PublicTestcases interface{} `yaml:"public_testcases"`
+}
+
+func ParseCmsYaml(content []byte) (res ParsedCmsYaml, err error) {
+	rawYaml := cmsRawYaml{}
+
+	err = yaml.Unmarshal(content, &rawYaml)
+	if err != nil {
+		return
+	}
+
+	res.ShortName = rawYaml.Name
+	res.Title = rawYaml.Title
+	res.CpuTimeLimitInSeconds = rawYaml.TimeLimit
+	res.MemoryLimitInMegabytes = rawYaml.MemoryLimit
+	res.NumberOfInputs = rawYaml.NInput
+	res.PublicTestcases = map[int]bool{}
+
+	switch v := rawYaml.PublicTestcases.(type) {
+	case nil:
+	case int:
+		res.PublicTestcases[v] = true
+	case string:
+		if strings.TrimSpace(v) == "all" {
+			res.PublicTestcases = nil
+			break
+		}
+		for _, part := range strings.Split(v, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			var no int
+			no, err = strconv.Atoi(part)
+			if err != nil {
+				err = fmt.Errorf("unsupported public testcases: %v", v)
+				return
+			}
+			res.PublicTestcases[no] = true
+		}
+	default:
+		err = fmt.Errorf("unsupported public testcases: %+v %T", v, v)
+		return
+	}
+
+	return
+}
+
+// CmsGenSubtask is a subtask declared by a "# ST: <points>" comment in
+// gen/GEN together with the number of testcases that follow it.
+type CmsGenSubtask struct {
+	Points        int
+	TestcaseCount int
+}
+
+/*
+Parses gen/GEN. Every non-empty line that is not a comment produces a
+testcase, as does every "#COPY:" line. Testcases belong to the subtask
+of the closest "# ST:" comment above them.
+
+	# ST: 0
+	#COPY: testo/esempio.in
+	# ST: 40
+	1 100
+	2 100
+*/
+func ParseCmsGen(content []byte) ([]CmsGenSubtask, error) {
+	res := []CmsGenSubtask{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			comment := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			switch {
+			case strings.HasPrefix(comment, "ST:"):
+				points, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(comment, "ST:")))
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid subtask points: %q", lineNo, line)
+				}
+				res = append(res, CmsGenSubtask{Points: points})
+			case strings.HasPrefix(comment, "COPY:"):
+				if len(res) == 0 {
+					return nil, fmt.Errorf("line %d: testcase before the first subtask", lineNo)
+				}
+				res[len(res)-1].TestcaseCount++
+			}
+			continue
+		}
+
+		if len(res) == 0 {
+			return nil, fmt.Errorf("line %d: testcase before the first subtask", lineNo)
+		}
+		res[len(res)-1].TestcaseCount++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ParseCmsTaskDir parses a task in the CMS italy_yaml layout. The
+// testcases must already be generated into input/ and output/.
+// Subtasks are taken from the "# ST:" comments in gen/GEN; subtasks worth
+// no points become examples. The checker and the graders are not part of
+// the task, see FindChecker and FindGraders.
+func ParseCmsTaskDir(dirPath string) (*fstaskparser.Task, []string, error) {
+	return ParseCmsTaskFS(os.DirFS(dirPath))
+}
+
+// ParseCmsTaskFS is ParseCmsTaskDir for the task directory at the root of
+// fsys.
+func ParseCmsTaskFS(fsys fs.FS) (*fstaskparser.Task, []string, error) {
+	return parseCmsTaskFS(context.Background(), fsys, nil)
+}
+
+func parseCmsTaskFS(ctx context.Context, fsys fs.FS, timings *StageTimings) (*fstaskparser.Task, []string, error) {
+	warnings := []string{}
+
+	done := timings.Start("yaml parse")
+	taskYamlContent, err := fs.ReadFile(fsys, "task.yaml")
+	if err != nil {
+		done()
+		return nil, nil, fmt.Errorf("failed to read task.yaml: %w", err)
+	}
+
+	parsedYaml, err := ParseCmsYaml(taskYamlContent)
+	done()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse task.yaml: %w", err)
+	}
+
+	title := parsedYaml.Title
+	if title == "" {
+		title = parsedYaml.ShortName
+		warnings = append(warnings, fmt.Sprintf("task has no title, using short name %q", title))
+	}
+
+	task, err := fstaskparser.NewTask(title)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create new task: %w", err)
+	}
+	task.SetCPUTimeLimitInSeconds(parsedYaml.CpuTimeLimitInSeconds)
+	task.SetMemoryLimitInMegabytes(parsedYaml.MemoryLimitInMegabytes)
+
+	subtasks, err := readCmsSubtasks(fsys, parsedYaml.NumberOfInputs, &warnings)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	done = timings.Start("test read")
+	err = addCmsTestcases(ctx, task, fsys, subtasks, parsedYaml.PublicTestcases, timings)
+	done()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	done = timings.Start("statement read")
+	err = addCmsStatements(task, fsys, &warnings)
+	done()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return task, warnings, nil
+}
+
+// addCmsTestcases adds the testcases of the subtasks, read from input/
+// and output/, to the task.
+func addCmsTestcases(ctx context.Context, task *fstaskparser.Task, fsys fs.FS, subtasks []CmsGenSubtask, publicTestcases map[int]bool, timings *StageTimings) error {
+	testcaseCount := 0
+	for _, st := range subtasks {
+		testcaseCount += st.TestcaseCount
+	}
+
+	testcaseNo := 0
+	groupID := 0
+	var bytesRead int64
+	for _, st := range subtasks {
+		testIDs := []int{}
+		public := true
+		for j := 0; j < st.TestcaseCount; j++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			input, err := fs.ReadFile(fsys, fmt.Sprintf("input/input%d.txt", testcaseNo))
+			if err != nil {
+				return fmt.Errorf("failed to read input of testcase %d: %w", testcaseNo, err)
+			}
+			output, err := fs.ReadFile(fsys, fmt.Sprintf("output/output%d.txt", testcaseNo))
+			if err != nil {
+				return fmt.Errorf("failed to read output of testcase %d: %w", testcaseNo, err)
+			}
+			bytesRead += int64(len(input) + len(output))
+			timings.TestsRead(testcaseNo+1, testcaseCount, bytesRead)
+
+			if publicTestcases != nil && !publicTestcases[testcaseNo] {
+				public = false
+			}
+
+			if st.Points == 0 {
+				task.AddExample(input, output)
+			} else {
+				id := task.AddTest(input, output)
+				task.AssignFilenameToTest(fmt.Sprintf("%03d", testcaseNo), id)
+				testIDs = append(testIDs, id)
+			}
+			testcaseNo++
+		}
+
+		if st.Points == 0 {
+			continue
+		}
+		groupID++
+		err := task.AddTestGroupWithID(groupID, st.Points, public, testIDs, groupID)
+		if err != nil {
+			return fmt.Errorf("failed to add test group: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func readCmsSubtasks(fsys fs.FS, nInput int, warnings *[]string) ([]CmsGenSubtask, error) {
+	genContent, err := fs.ReadFile(fsys, "gen/GEN")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read gen/GEN: %w", err)
+	}
+
+	if err == nil {
+		subtasks, err := ParseCmsGen(genContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse gen/GEN: %w", err)
+		}
+		if len(subtasks) > 0 {
+			total := 0
+			for _, st := range subtasks {
+				total += st.TestcaseCount
+			}
+			if nInput != 0 && total != nInput {
+				return nil, fmt.Errorf("gen/GEN describes %d testcases, task.yaml has n_input %d", total, nInput)
+			}
+			return subtasks, nil
+		}
+	}
+
+	if nInput == 0 {
+		inputs, err := fs.Glob(fsys, "input/input*.txt")
+		if err != nil {
+			return nil, fmt.Errorf("failed to find inputs: %w", err)
+		}
+		nInput = len(inputs)
+	}
+	if nInput == 0 {
+		return nil, fmt.Errorf("no testcases found in input/")
+	}
+
+	// without subtasks every testcase is scored on its own
+	*warnings = append(*warnings, "gen/GEN has no subtasks, distributing 100 points evenly among the testcases")
+	res := []CmsGenSubtask{}
+	for i := 0; i < nInput; i++ {
+		points := 100 / nInput
+		if i >= nInput-100%nInput {
+			points++
+		}
+		res = append(res, CmsGenSubtask{Points: points, TestcaseCount: 1})
+	}
+	return res, nil
+}
+
+func addCmsStatements(task *fstaskparser.Task, fsys fs.FS, warnings *[]string) error {
+	for _, statementDir := range []string{"statement", "testo"} {
+		pdfFiles, err := fs.Glob(fsys, path.Join(statementDir, "*.pdf"))
+		if err != nil {
+			return fmt.Errorf("failed to find PDF files: %w", err)
+		}
+		if len(pdfFiles) == 0 {
+			continue
+		}
+		if len(pdfFiles) > 1 {
+			*warnings = append(*warnings, fmt.Sprintf("%d PDF files found, using %s as the statement",
+				len(pdfFiles), path.Base(pdfFiles[0])))
+		}
+
+		pdfBytes, err := fs.ReadFile(fsys, pdfFiles[0])
+		if err != nil {
+			return fmt.Errorf("failed to read PDF file: %w", err)
+		}
+
+		// CMS does not record the language of the statement
+		*warnings = append(*warnings, fmt.Sprintf("statement %s is assumed to be in Latvian", path.Base(pdfFiles[0])))
+		err = task.AddPDFStatement("lv", pdfBytes)
+		if err != nil {
+			return fmt.Errorf("failed to add PDF statement: %w", err)
+		}
+		return nil
+	}
+
+	*warnings = append(*warnings, "no PDF statement found, importing the task without a statement")
+	return nil
+}
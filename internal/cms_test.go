@@ -0,0 +1,95 @@
+package internal_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCmsTaskDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "gen"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "input"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "output"), 0755))
+
+	taskYaml := `name: kp
+title: Kvadrātveida putekļsūcējs
+time_limit: 1.5
+memory_limit: 512
+n_input: 4
+public_testcases: 0,1,2
+`
+	gen := `# ST: 0
+#COPY: testo/esempio.in
+# ST: 40
+1 10
+2 10
+# ST: 60
+3 1000000
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "task.yaml"), []byte(taskYaml), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gen", "GEN"), []byte(gen), 0644))
+	for i := 0; i < 4; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "input", fmt.Sprintf("input%d.txt", i)), []byte(fmt.Sprintf("%d\n", i)), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "output", fmt.Sprintf("output%d.txt", i)), []byte(fmt.Sprintf("%d\n", i*i)), 0644))
+	}
+
+	imp, err := internal.DetectImporter(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "cms", imp.Name())
+
+	task, _, err := internal.ParseCmsTaskDir(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Kvadrātveida putekļsūcējs", task.GetTaskName())
+	assert.Equal(t, 1.5, task.GetCPUTimeLimitInSeconds())
+	assert.Equal(t, 512, task.GetMemoryLimitInMegabytes())
+	assert.Len(t, task.GetExamples(), 1)
+	assert.Len(t, task.GetTestsSortedByID(), 3)
+
+	require.Equal(t, []int{1, 2}, task.GetTestGroupIDs())
+	assert.Equal(t, 40, task.GetInfoOnTestGroup(1).Points)
+	assert.True(t, task.GetInfoOnTestGroup(1).Public)
+	assert.Equal(t, 60, task.GetInfoOnTestGroup(2).Points)
+	assert.False(t, task.GetInfoOnTestGroup(2).Public)
+
+}
+
+func TestCmsCheckerAndGraders(t *testing.T) {
+	fsys := fstest.MapFS{
+		"task.yaml":          &fstest.MapFile{Data: []byte("name: kp\n")},
+		"cor/correttore.cpp": &fstest.MapFile{Data: []byte("int main() {}\n")},
+		"cor/correttore":     &fstest.MapFile{Data: []byte("\x7fELF")},
+		"sol/grader.cpp":     &fstest.MapFile{Data: []byte("int main() {}\n")},
+		"sol/grader.c":       &fstest.MapFile{Data: []byte("int main() {}\n")},
+		"sol/kp.h":           &fstest.MapFile{Data: []byte("\n")},
+		"sol/soluzione.cpp":  &fstest.MapFile{Data: []byte("int kp() {}\n")},
+	}
+	imp, err := internal.GetImporter("cms")
+	require.NoError(t, err)
+
+	checker, err := imp.(internal.CheckerFinder).FindChecker(fsys)
+	require.NoError(t, err)
+	require.Len(t, checker, 1)
+	assert.Equal(t, "correttore.cpp", checker[0].Filename)
+	assert.Equal(t, internal.CheckerCms, imp.(internal.CheckerFinder).CheckerKind())
+
+	graders, err := imp.(internal.GraderFinder).FindGraders(fsys)
+	require.NoError(t, err)
+	filenames := []string{}
+	for _, f := range graders {
+		filenames = append(filenames, f.Filename)
+	}
+	assert.Equal(t, []string{"grader.c", "grader.cpp", "kp.h"}, filenames)
+
+	// a checker only shipped compiled cannot be imported
+	delete(fsys, "cor/correttore.cpp")
+	_, err = imp.(internal.CheckerFinder).FindChecker(fsys)
+	assert.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LioTestDuplicate is a set of tests sharing the same input.
+type LioTestDuplicate struct {
+	// Tests are the labels of the tests, such as 1a, in the order given.
+	Tests []string
+	// Conflict tells that the answers differ, which is always a mistake.
+	Conflict bool
+}
+
+func (d LioTestDuplicate) String() string {
+	if d.Conflict {
+		return fmt.Sprintf("tests %s have the same input but different answers", strings.Join(d.Tests, ", "))
+	}
+	return fmt.Sprintf("tests %s are identical", strings.Join(d.Tests, ", "))
+}
+
+// FindDuplicateLioTests hashes the inputs and answers of the tests and
+// returns the sets of tests with the same input. Examples repeated as
+// tests are expected and not reported, unless the answers differ.
+func FindDuplicateLioTests(tests []LioTest) []LioTestDuplicate {
+	type hashedTest struct {
+		label     string
+		answer    [sha256.Size]byte
+		isExample bool
+	}
+
+	byInput := map[[sha256.Size]byte][]hashedTest{}
+	inputOrder := [][sha256.Size]byte{}
+	for _, t := range tests {
+		input := sha256.Sum256(t.Input)
+		if _, ok := byInput[input]; !ok {
+			inputOrder = append(inputOrder, input)
+		}
+		byInput[input] = append(byInput[input], hashedTest{
+			label:     lioTestLabel(t),
+			answer:    sha256.Sum256(t.Answer),
+			isExample: t.TestGroup == 0,
+		})
+	}
+
+	res := []LioTestDuplicate{}
+	for _, input := range inputOrder {
+		same := byInput[input]
+		if len(same) < 2 {
+			continue
+		}
+
+		duplicate := LioTestDuplicate{}
+		tests := 0
+		for _, t := range same {
+			duplicate.Tests = append(duplicate.Tests, t.label)
+			if t.answer != same[0].answer {
+				duplicate.Conflict = true
+			}
+			if !t.isExample {
+				tests++
+			}
+		}
+		if !duplicate.Conflict && tests < 2 {
+			continue
+		}
+		res = append(res, duplicate)
+	}
+	return res
+}
+
+// checkDuplicateLioTests returns a warning for every set of identical
+// tests and an ErrConflictingAnswers error for the sets whose answers
+// differ, see FindDuplicateLioTests.
+func checkDuplicateLioTests(tests []LioTest) ([]string, error) {
+	warnings := []string{}
+	errs := ErrorList{}
+	for _, d := range FindDuplicateLioTests(tests) {
+		if d.Conflict {
+			errs = append(errs, importErrorf(CodeConflictingAnswers, "", 0, "%s", d))
+			continue
+		}
+		warnings = append(warnings, d.String())
+	}
+	return warnings, errs.Err()
+}
+
+// LinkDuplicateTestFiles replaces the test and example files of a stored
+// task directory that have the same content as an earlier one, in
+// filename order, with hard links to it, so that shared content is
+// stored once. The programme.lv format has no way to refer to another
+// test's file, but readers of it cannot tell a hard link from a copy.
+// It returns the number of files linked and the bytes saved.
+func LinkDuplicateTestFiles(taskDir string) (int, int64, error) {
+	linked := 0
+	var saved int64
+	for _, dir := range []string{"tests", "examples"} {
+		first := map[[sha256.Size]byte]string{}
+
+		entries, err := os.ReadDir(filepath.Join(taskDir, dir))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() {
+				continue
+			}
+			fpath := filepath.Join(taskDir, dir, entry.Name())
+			content, err := os.ReadFile(fpath)
+			if err != nil {
+				return 0, 0, err
+			}
+			hash := sha256.Sum256(content)
+			original, ok := first[hash]
+			if !ok {
+				first[hash] = fpath
+				continue
+			}
+
+			err = linkOver(original, fpath)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to link %s to %s: %w", fpath, original, err)
+			}
+			linked++
+			saved += int64(len(content))
+		}
+	}
+	return linked, saved, nil
+}
+
+// linkOver replaces the file at path with a hard link to original.
+func linkOver(original string, path string) error {
+	tmpPath := path + ".link"
+	err := os.Link(original, tmpPath)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package internal_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicateLioTests(t *testing.T) {
+	tests := []internal.LioTest{
+		{TestGroup: 0, NoInTestGroup: 1, Input: []byte("1\n"), Answer: []byte("2\n")},
+		{TestGroup: 1, NoInTestGroup: 1, Input: []byte("1\n"), Answer: []byte("2\n")},
+		{TestGroup: 1, NoInTestGroup: 2, Input: []byte("3\n"), Answer: []byte("4\n")},
+		{TestGroup: 2, NoInTestGroup: 1, Input: []byte("3\n"), Answer: []byte("4\n")},
+		{TestGroup: 2, NoInTestGroup: 2, Input: []byte("5\n"), Answer: []byte("6\n")},
+		{TestGroup: 3, NoInTestGroup: 1, Input: []byte("5\n"), Answer: []byte("7\n")},
+	}
+
+	duplicates := internal.FindDuplicateLioTests(tests)
+	assert.Equal(t, []internal.LioTestDuplicate{
+		{Tests: []string{"1b", "2a"}},
+		{Tests: []string{"2b", "3a"}, Conflict: true},
+	}, duplicates)
+	assert.Equal(t, "tests 1b, 2a are identical", duplicates[0].String())
+	assert.Equal(t, "tests 2b, 3a have the same input but different answers", duplicates[1].String())
+}
+
+func TestParseTaskFSDuplicateTests(t *testing.T) {
+	fsys := fstest.MapFS{
+		"testi/kp.i1": &fstest.MapFile{Data: []byte("1\n")},
+		"testi/kp.o1": &fstest.MapFile{Data: []byte("2\n")},
+		"testi/kp.i2": &fstest.MapFile{Data: []byte("1\n")},
+		"testi/kp.o2": &fstest.MapFile{Data: []byte("2\n")},
+	}
+	imp, err := internal.GetImporter("lio-legacy")
+	require.NoError(t, err)
+
+	_, warnings, err := internal.ParseTaskFS(context.Background(), imp, fsys, "kp",
+		internal.ParseOptions{}, &internal.StageTimings{})
+	require.NoError(t, err)
+	assert.Contains(t, warnings, "tests 1a, 2a are identical")
+
+	// different answers to the same input are always a mistake
+	fsys["testi/kp.o2"] = &fstest.MapFile{Data: []byte("3\n")}
+	_, _, err = internal.ParseTaskFS(context.Background(), imp, fsys, "kp",
+		internal.ParseOptions{}, &internal.StageTimings{})
+	assert.ErrorIs(t, err, internal.ErrConflictingAnswers)
+	assert.EqualError(t, err, "tests 1a, 2a have the same input but different answers")
+}
+
+func TestLinkDuplicateTestFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"tests/001_a.in":  "1\n",
+		"tests/001_a.out": "2\n",
+		"tests/002_a.in":  "1\n",
+		"tests/002_a.out": "3\n",
+		"tests/003_a.in":  "2\n",
+		"tests/003_a.out": "2\n",
+	})
+
+	linked, saved, err := internal.LinkDuplicateTestFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, linked)
+	assert.Equal(t, int64(6), saved)
+
+	stat := func(name string) os.FileInfo {
+		info, err := os.Stat(filepath.Join(dir, "tests", name))
+		require.NoError(t, err)
+		return info
+	}
+	assert.True(t, os.SameFile(stat("001_a.in"), stat("002_a.in")))
+	assert.True(t, os.SameFile(stat("001_a.out"), stat("003_a.in")))
+	assert.False(t, os.SameFile(stat("001_a.in"), stat("003_a.out")))
+
+	content, err := os.ReadFile(filepath.Join(dir, "tests", "002_a.in"))
+	require.NoError(t, err)
+	assert.Equal(t, "1\n", string(content))
+
+	// an update must not write through a link into the other test
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"tests/001_a.in":  "1\n",
+		"tests/001_a.out": "2\n",
+		"tests/002_a.in":  "9\n",
+		"tests/002_a.out": "3\n",
+		"tests/003_a.in":  "2\n",
+		"tests/003_a.out": "2\n",
+	})
+	_, err = internal.UpdateTaskDir(src, dir)
+	require.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(dir, "tests", "001_a.in"))
+	require.NoError(t, err)
+	assert.Equal(t, "1\n", string(content))
+}
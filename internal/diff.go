@@ -0,0 +1,308 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+)
+
+// DiffTasks describes how task b differs from task a, one difference per
+// line. Tests are matched by filename, unmatched tests with the same
+// content are reported as renamed. It returns nil if the tasks are equal.
+func DiffTasks(a, b *fstaskparser.Task) []string {
+	res := []string{}
+	addf := func(format string, args ...interface{}) {
+		res = append(res, fmt.Sprintf(format, args...))
+	}
+
+	if a.GetTaskName() != b.GetTaskName() {
+		addf("name: %q -> %q", a.GetTaskName(), b.GetTaskName())
+	}
+	if a.GetCPUTimeLimitInSeconds() != b.GetCPUTimeLimitInSeconds() {
+		addf("time limit: %gs -> %gs", a.GetCPUTimeLimitInSeconds(), b.GetCPUTimeLimitInSeconds())
+	}
+	if a.GetMemoryLimitInMegabytes() != b.GetMemoryLimitInMegabytes() {
+		addf("memory limit: %d MB -> %d MB", a.GetMemoryLimitInMegabytes(), b.GetMemoryLimitInMegabytes())
+	}
+	if !stringSetsEqual(a.GetTaskAuthors(), b.GetTaskAuthors()) {
+		addf("authors: %v -> %v", a.GetTaskAuthors(), b.GetTaskAuthors())
+	}
+	if !stringSetsEqual(a.GetProblemTags(), b.GetProblemTags()) {
+		addf("tags: %v -> %v", a.GetProblemTags(), b.GetProblemTags())
+	}
+	if a.GetDifficultyOneToFive() != b.GetDifficultyOneToFive() {
+		addf("difficulty: %d -> %d", a.GetDifficultyOneToFive(), b.GetDifficultyOneToFive())
+	}
+	if a.GetOriginOlympiad() != b.GetOriginOlympiad() {
+		addf("olympiad: %q -> %q", a.GetOriginOlympiad(), b.GetOriginOlympiad())
+	}
+
+	aExamples, bExamples := a.GetExamples(), b.GetExamples()
+	for i := 0; i < len(aExamples) || i < len(bExamples); i++ {
+		name := fmt.Sprintf("example %d", i+1)
+		switch {
+		case i >= len(aExamples):
+			addf("added %s", name)
+		case i >= len(bExamples):
+			addf("removed %s", name)
+		default:
+			res = append(res, diffTestContent(name, "input", aExamples[i].Input, bExamples[i].Input)...)
+			res = append(res, diffTestContent(name, "output", aExamples[i].Output, bExamples[i].Output)...)
+		}
+	}
+
+	res = append(res, diffTests(a, b)...)
+	res = append(res, diffTestGroups(a, b)...)
+	res = append(res, diffStatements(a, b)...)
+
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
+
+type diffTest struct {
+	name   string
+	input  []byte
+	answer []byte
+}
+
+func (t diffTest) hash() string {
+	h := sha256.New()
+	h.Write(t.input)
+	h.Write([]byte{0})
+	h.Write(t.answer)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func diffTestsOf(task *fstaskparser.Task) map[string]diffTest {
+	res := map[string]diffTest{}
+	for _, t := range task.GetTestsSortedByID() {
+		name := testFilename(task, t.ID)
+		res[name] = diffTest{name: name, input: t.Input, answer: t.Answer}
+	}
+	return res
+}
+
+func diffTests(a, b *fstaskparser.Task) []string {
+	res := []string{}
+	aTests, bTests := diffTestsOf(a), diffTestsOf(b)
+
+	removed := []diffTest{}
+	for _, name := range sortedKeys(aTests) {
+		at := aTests[name]
+		bt, ok := bTests[name]
+		if !ok {
+			removed = append(removed, at)
+			continue
+		}
+		res = append(res, diffTestContent("test "+name, "input", at.input, bt.input)...)
+		res = append(res, diffTestContent("test "+name, "answer", at.answer, bt.answer)...)
+	}
+
+	added := []diffTest{}
+	for _, name := range sortedKeys(bTests) {
+		if _, ok := aTests[name]; !ok {
+			added = append(added, bTests[name])
+		}
+	}
+
+	renamedTo := map[string]bool{}
+	for _, at := range removed {
+		renamed := false
+		for _, bt := range added {
+			if !renamedTo[bt.name] && bt.hash() == at.hash() {
+				res = append(res, fmt.Sprintf("renamed test %s -> %s", at.name, bt.name))
+				renamedTo[bt.name] = true
+				renamed = true
+				break
+			}
+		}
+		if !renamed {
+			res = append(res, fmt.Sprintf("removed test %s", at.name))
+		}
+	}
+	for _, bt := range added {
+		if !renamedTo[bt.name] {
+			res = append(res, fmt.Sprintf("added test %s (%s)", bt.name, shortHash(bt.hash())))
+		}
+	}
+
+	return res
+}
+
+// diffTestContent reports a change of one file of a test along with the
+// first line that differs.
+func diffTestContent(name, part string, a, b []byte) []string {
+	if bytes.Equal(a, b) {
+		return nil
+	}
+	aLines := strings.Split(string(a), "\n")
+	bLines := strings.Split(string(b), "\n")
+	line := 0
+	for line < len(aLines) && line < len(bLines) && aLines[line] == bLines[line] {
+		line++
+	}
+	lineAt := func(lines []string) string {
+		if line >= len(lines) {
+			return "<end of file>"
+		}
+		return fmt.Sprintf("%q", previewLine(lines[line]))
+	}
+	return []string{fmt.Sprintf("changed %s %s (%s -> %s), line %d: %s -> %s",
+		name, part, shortHash(sha256Hex(a)), shortHash(sha256Hex(b)),
+		line+1, lineAt(aLines), lineAt(bLines))}
+}
+
+func diffTestGroups(a, b *fstaskparser.Task) []string {
+	res := []string{}
+	groupIDs := map[int]bool{}
+	for _, id := range a.GetTestGroupIDs() {
+		groupIDs[id] = true
+	}
+	for _, id := range b.GetTestGroupIDs() {
+		groupIDs[id] = true
+	}
+	sortedIDs := []int{}
+	for id := range groupIDs {
+		sortedIDs = append(sortedIDs, id)
+	}
+	sort.Ints(sortedIDs)
+
+	aHas := intSet(a.GetTestGroupIDs())
+	bHas := intSet(b.GetTestGroupIDs())
+	for _, id := range sortedIDs {
+		if !aHas[id] {
+			g := b.GetInfoOnTestGroup(id)
+			res = append(res, fmt.Sprintf("added group %d (%d points, subtask %d)", id, g.Points, g.Subtask))
+			continue
+		}
+		if !bHas[id] {
+			res = append(res, fmt.Sprintf("removed group %d", id))
+			continue
+		}
+
+		ag, bg := a.GetInfoOnTestGroup(id), b.GetInfoOnTestGroup(id)
+		if ag.Points != bg.Points {
+			res = append(res, fmt.Sprintf("group %d points: %d -> %d", id, ag.Points, bg.Points))
+		}
+		if ag.Subtask != bg.Subtask {
+			res = append(res, fmt.Sprintf("group %d subtask: %d -> %d", id, ag.Subtask, bg.Subtask))
+		}
+		if ag.Public != bg.Public {
+			res = append(res, fmt.Sprintf("group %d public: %t -> %t", id, ag.Public, bg.Public))
+		}
+		aNames, bNames := groupTestNames(a, ag.TestIDs), groupTestNames(b, bg.TestIDs)
+		if !reflect.DeepEqual(aNames, bNames) {
+			res = append(res, fmt.Sprintf("group %d tests: %v -> %v", id, aNames, bNames))
+		}
+	}
+
+	aTotal, bTotal := totalPoints(a), totalPoints(b)
+	if aTotal != bTotal {
+		res = append(res, fmt.Sprintf("total points: %d -> %d", aTotal, bTotal))
+	}
+	return res
+}
+
+func diffStatements(a, b *fstaskparser.Task) []string {
+	res := []string{}
+	aPdfs, bPdfs := map[string][]byte{}, map[string][]byte{}
+	for _, st := range a.GetAllPDFStatements() {
+		aPdfs[st.Language] = st.Statement
+	}
+	for _, st := range b.GetAllPDFStatements() {
+		bPdfs[st.Language] = st.Statement
+	}
+	langs := map[string]bool{}
+	for lang := range aPdfs {
+		langs[lang] = true
+	}
+	for lang := range bPdfs {
+		langs[lang] = true
+	}
+	for _, lang := range sortedKeys(langs) {
+		aPdf, aOk := aPdfs[lang]
+		bPdf, bOk := bPdfs[lang]
+		switch {
+		case !aOk:
+			res = append(res, fmt.Sprintf("added %s PDF statement", lang))
+		case !bOk:
+			res = append(res, fmt.Sprintf("removed %s PDF statement", lang))
+		case !bytes.Equal(aPdf, bPdf):
+			res = append(res, fmt.Sprintf("changed %s PDF statement (%s -> %s)", lang,
+				shortHash(sha256Hex(aPdf)), shortHash(sha256Hex(bPdf))))
+		}
+	}
+
+	aMd, bMd := a.GetMarkdownStatements(), b.GetMarkdownStatements()
+	if (len(aMd) > 0 || len(bMd) > 0) && !reflect.DeepEqual(aMd, bMd) {
+		res = append(res, "changed Markdown statements")
+	}
+	return res
+}
+
+func groupTestNames(task *fstaskparser.Task, testIDs []int) []string {
+	res := []string{}
+	for _, id := range testIDs {
+		res = append(res, testFilename(task, id))
+	}
+	return res
+}
+
+func totalPoints(task *fstaskparser.Task) int {
+	res := 0
+	for _, id := range task.GetTestGroupIDs() {
+		res += task.GetInfoOnTestGroup(id).Points
+	}
+	return res
+}
+
+func previewLine(line string) string {
+	const maxPreview = 40
+	runes := []rune(line)
+	if len(runes) > maxPreview {
+		return string(runes[:maxPreview]) + "..."
+	}
+	return line
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func shortHash(hash string) string {
+	return hash[:8]
+}
+
+func stringSetsEqual(a, b []string) bool {
+	as := append([]string{}, a...)
+	bs := append([]string{}, b...)
+	sort.Strings(as)
+	sort.Strings(bs)
+	return strings.Join(as, "\x00") == strings.Join(bs, "\x00")
+}
+
+func intSet(ints []int) map[int]bool {
+	res := map[int]bool{}
+	for _, i := range ints {
+		res[i] = true
+	}
+	return res
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
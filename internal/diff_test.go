@@ -0,0 +1,44 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDiffTask(t *testing.T, timeLimit float64, tests map[string]string, points int) *fstaskparser.Task {
+	task, err := fstaskparser.NewTask("Kp")
+	require.NoError(t, err)
+	task.SetCPUTimeLimitInSeconds(timeLimit)
+	task.SetMemoryLimitInMegabytes(256)
+	ids := []int{}
+	for _, name := range []string{"001_a", "001_b", "001_c"} {
+		input, ok := tests[name]
+		if !ok {
+			continue
+		}
+		id := task.AddTest([]byte(input), []byte("ok\n"))
+		task.AssignFilenameToTest(name, id)
+		ids = append(ids, id)
+	}
+	require.NoError(t, task.AddTestGroupWithID(1, points, false, ids, 1))
+	return task
+}
+
+func TestDiffTasks(t *testing.T) {
+	a := newDiffTask(t, 0.5, map[string]string{"001_a": "1 2\n3 4\n", "001_b": "5\n"}, 40)
+	assert.Nil(t, internal.DiffTasks(a, a))
+
+	b := newDiffTask(t, 1, map[string]string{"001_a": "1 2\n3 5\n", "001_c": "5\n"}, 50)
+	assert.Equal(t, []string{
+		"time limit: 0.5s -> 1s",
+		`changed test 001_a input (871d6e7f -> 425104cb), line 2: "3 4" -> "3 5"`,
+		"renamed test 001_b -> 001_c",
+		"group 1 points: 40 -> 50",
+		"group 1 tests: [001_a 001_b] -> [001_a 001_c]",
+		"total points: 40 -> 50",
+	}, internal.DiffTasks(a, b))
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+)
+
+func init() {
+	RegisterExporter(domjudgeExporter{})
+}
+
+type domjudgeExporter struct{}
+
+func (domjudgeExporter) Name() string { return "domjudge" }
+
+func (domjudgeExporter) Description() string {
+	return "DOMjudge problem zip with domjudge-problem.ini and the checker as output validator"
+}
+
+func (domjudgeExporter) Extension() string { return ".zip" }
+
+func (domjudgeExporter) Export(task *fstaskparser.Task, destPath string, opts ExportOptions) error {
+	return ExportDomjudgeZip(task, destPath, opts)
+}
+
+// ExportDomjudgeZip writes the task as a DOMjudge problem zip. DOMjudge
+// judges pass-fail, so test groups and points are dropped and every test
+// is placed directly in data/secret. The checker is written as the output
+// validator like in ExportKattisPackage.
+func ExportDomjudgeZip(task *fstaskparser.Task, zipPath string, opts ExportOptions) error {
+	if _, err := os.Stat(zipPath); !os.IsNotExist(err) {
+		return fmt.Errorf("file already exists: %s", zipPath)
+	}
+	if len(opts.Graders) > 0 {
+		return fmt.Errorf("%w: DOMjudge problems have no graders (found %s)", ErrGradersNotSupported, opts.Graders[0].Filename)
+	}
+
+	tmpDirPath, err := os.MkdirTemp("", "domjudge-problem")
+	if err != nil {
+		return fmt.Errorf("failed to create tmp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDirPath)
+
+	err = writeDomjudgeProblemIni(task, tmpDirPath, opts.ShortName)
+	if err != nil {
+		return err
+	}
+
+	err = writeKattisProblemYaml(task, tmpDirPath, "pass-fail", opts)
+	if err != nil {
+		return err
+	}
+
+	err = writeKattisSamples(task, tmpDirPath)
+	if err != nil {
+		return err
+	}
+
+	secretDir := filepath.Join(tmpDirPath, "data", "secret")
+	err = os.MkdirAll(secretDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create secret directory: %w", err)
+	}
+	for _, t := range task.GetTestsSortedByID() {
+		err = writeTestCase(secretDir, testFilename(task, t.ID), t.Input, t.Answer, ".ans")
+		if err != nil {
+			return err
+		}
+	}
+
+	statements := task.GetAllPDFStatements()
+	sort.Slice(statements, func(i, j int) bool {
+		// DOMjudge takes a single statement, prefer the Latvian one
+		if (statements[i].Language == "lv") != (statements[j].Language == "lv") {
+			return statements[i].Language == "lv"
+		}
+		return statements[i].Language < statements[j].Language
+	})
+	if len(statements) > 0 {
+		err = os.WriteFile(filepath.Join(tmpDirPath, "problem.pdf"), statements[0].Statement, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write PDF statement: %w", err)
+		}
+	}
+
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", zipPath, err)
+	}
+	defer zipFile.Close()
+
+	err = ZipDir(tmpDirPath, zipFile)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", zipPath, err)
+	}
+
+	return zipFile.Close()
+}
+
+func writeDomjudgeProblemIni(task *fstaskparser.Task, dirPath string, shortName string) error {
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, `'`) + `"`
+	}
+
+	ini := strings.Builder{}
+	if shortName != "" {
+		fmt.Fprintf(&ini, "externalid = %s\n", quote(shortName))
+		fmt.Fprintf(&ini, "short-name = %s\n", quote(shortName))
+	}
+	fmt.Fprintf(&ini, "name = %s\n", quote(task.GetTaskName()))
+	fmt.Fprintf(&ini, "timelimit = %s\n", strconv.FormatFloat(task.GetCPUTimeLimitInSeconds(), 'f', -1, 64))
+
+	err := os.WriteFile(filepath.Join(dirPath, "domjudge-problem.ini"), []byte(ini.String()), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write domjudge-problem.ini: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package internal_test
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomjudgeExport(t *testing.T) {
+	task, err := fstaskparser.NewTask("Kvadrātveida putekļsūcējs")
+	require.NoError(t, err)
+	task.SetCPUTimeLimitInSeconds(0.5)
+	task.SetMemoryLimitInMegabytes(128)
+	task.AddExample([]byte("1 2\n"), []byte("3\n"))
+	a := task.AddTest([]byte("5 5\n"), []byte("10\n"))
+	b := task.AddTest([]byte("1 1\n"), []byte("2\n"))
+	require.NoError(t, task.AddTestGroupWithID(1, 40, true, []int{a, b}, 1))
+	require.NoError(t, task.AddPDFStatement("en", []byte("%PDF en")))
+	require.NoError(t, task.AddPDFStatement("lv", []byte("%PDF lv")))
+
+	zipPath := filepath.Join(t.TempDir(), "kp.zip")
+	err = internal.ExportDomjudgeZip(task, zipPath, internal.ExportOptions{
+		ShortName: "kp",
+		Checker: []internal.SourceFile{
+			{Filename: "checker.cpp", Content: []byte("int main() {}\n")},
+			{Filename: "testlib.h", Content: []byte("// testlib\n")},
+		},
+		CheckerKind: internal.CheckerTestlib,
+	})
+	require.NoError(t, err)
+
+	r, err := zip.OpenReader(zipPath)
+	require.NoError(t, err)
+	defer r.Close()
+
+	files := map[string]string{}
+	modes := map[string]os.FileMode{}
+	for _, f := range r.File {
+		modes[f.Name] = f.Mode()
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+
+	assert.Equal(t, "externalid = \"kp\"\nshort-name = \"kp\"\n"+
+		"name = \"Kvadrātveida putekļsūcējs\"\ntimelimit = 0.5\n", files["domjudge-problem.ini"])
+	assert.Contains(t, files["problem.yaml"], "type: pass-fail")
+	assert.Contains(t, files["problem.yaml"], "validation: custom")
+	assert.Equal(t, "1 2\n", files["data/sample/001.in"])
+	assert.Equal(t, "5 5\n", files["data/secret/001.in"])
+	assert.Equal(t, "2\n", files["data/secret/002.ans"])
+	assert.Equal(t, "int main() {}\n", files["output_validators/checker/checker.cpp"])
+	assert.Equal(t, "// testlib\n", files["output_validators/checker/testlib.h"])
+	assert.Contains(t, files["output_validators/checker/build"], "-o testlib_checker 'checker.cpp'")
+	assert.Contains(t, files["output_validators/checker/run"], "exit 42")
+	assert.Equal(t, os.FileMode(0755), modes["output_validators/checker/run"])
+	assert.Equal(t, os.FileMode(0644), modes["output_validators/checker/checker.cpp"])
+	assert.Equal(t, "%PDF lv", files["problem.pdf"])
+
+	err = internal.ExportDomjudgeZip(task, zipPath, internal.ExportOptions{})
+	assert.Error(t, err)
+}
+
+func TestDomjudgeExportGraders(t *testing.T) {
+	task, err := fstaskparser.NewTask("Kp")
+	require.NoError(t, err)
+
+	err = internal.ExportDomjudgeZip(task, filepath.Join(t.TempDir(), "kp.zip"), internal.ExportOptions{
+		ShortName: "kp",
+		Graders:   []internal.SourceFile{{Filename: "grader.cpp", Content: []byte("int main() {}\n")}},
+	})
+	assert.ErrorIs(t, err, internal.ErrGradersNotSupported)
+}
+
+func TestDomjudgeExportCmsChecker(t *testing.T) {
+	task, err := fstaskparser.NewTask("Kp")
+	require.NoError(t, err)
+
+	err = internal.ExportDomjudgeZip(task, filepath.Join(t.TempDir(), "kp.zip"), internal.ExportOptions{
+		ShortName:   "kp",
+		Checker:     []internal.SourceFile{{Filename: "checker.cpp", Content: []byte("int main() {}\n")}},
+		CheckerKind: internal.CheckerCms,
+	})
+	assert.ErrorIs(t, err, internal.ErrCheckerNotSupported)
+}
+
+// testlibStyleChecker accepts the output if it is the answer plus one,
+// reading the input, output and answer from files and exiting with 0 or 1
+// like testlib checkers do.
+var testlibStyleChecker = `#include <fstream>
+int main(int argc, char **argv) {
+	std::ifstream in(argv[1]), out(argv[2]), ans(argv[3]);
+	long long o, a;
+	if (!(out >> o) || !(ans >> a)) return 2;
+	return o == a + 1 ? 0 : 1;
+}
+`
+
+func TestDomjudgeExportTestlibChecker(t *testing.T) {
+	if _, err := exec.LookPath("g++"); err != nil {
+		t.Skip("g++ not found")
+	}
+
+	task, err := fstaskparser.NewTask("Kp")
+	require.NoError(t, err)
+	a := task.AddTest([]byte("1\n"), []byte("2\n"))
+	require.NoError(t, task.AddTestGroupWithID(1, 100, false, []int{a}, 1))
+
+	zipPath := filepath.Join(t.TempDir(), "kp.zip")
+	require.NoError(t, internal.ExportDomjudgeZip(task, zipPath, internal.ExportOptions{
+		ShortName:   "kp",
+		Checker:     []internal.SourceFile{{Filename: "checker.cpp", Content: []byte(testlibStyleChecker)}},
+		CheckerKind: internal.CheckerTestlib,
+	}))
+
+	// build and run the output validator as DOMjudge does
+	dir := t.TempDir()
+	require.NoError(t, internal.ExtractArchive(zipPath, dir))
+	validatorDir := filepath.Join(dir, "output_validators", "checker")
+	build := exec.Command("sh", "build")
+	build.Dir = validatorDir
+	out, err := build.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	for teamOutput, exitCode := range map[string]int{"3\n": 42, "2\n": 43} {
+		feedbackDir := t.TempDir()
+		run := exec.Command(filepath.Join(validatorDir, "run"),
+			filepath.Join(dir, "data", "secret", "001.in"), filepath.Join(dir, "data", "secret", "001.ans"), feedbackDir)
+		run.Stdin = strings.NewReader(teamOutput)
+		err = run.Run()
+		exitErr := &exec.ExitError{}
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, exitCode, exitErr.ExitCode(), "team output %q", teamOutput)
+	}
+}
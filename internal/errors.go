@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrorCode identifies a kind of import error. Codes are stable: they are
+// written to JSON reports and matched on by tools, so a code is never
+// renamed or given another meaning.
+type ErrorCode string
+
+// Import error codes.
+const (
+	// CodeMissingFile is a file the task needs that is not there.
+	CodeMissingFile ErrorCode = "missing_file"
+	// CodeBadTestFilename is a test file not named like kp.i01a or
+	// kp.o01a, or an input without a matching answer.
+	CodeBadTestFilename ErrorCode = "bad_test_filename"
+	// CodeGroupMismatch is a disagreement between the test groups of the
+	// tests and the ones in task.yaml or the points file.
+	CodeGroupMismatch ErrorCode = "group_mismatch"
+	// CodeLimitExceeded is a value outside of its allowed range, such as
+	// the time limit or the difficulty.
+	CodeLimitExceeded ErrorCode = "limit_exceeded"
+	// CodeInvalidSyntax is a task.yaml or points file that cannot be
+	// parsed.
+	CodeInvalidSyntax ErrorCode = "invalid_syntax"
+	// CodeEmptyAnswer is a test with an empty answer.
+	CodeEmptyAnswer ErrorCode = "empty_answer"
+	// CodeConflictingAnswers is tests with the same input but different
+	// answers.
+	CodeConflictingAnswers ErrorCode = "conflicting_answers"
+	// CodeUnknownTag is a tag missing from the tag vocabulary.
+	CodeUnknownTag ErrorCode = "unknown_tag"
+)
+
+// Sentinels to test the code of an error with errors.Is, e.g.
+// errors.Is(err, ErrMissingFile) holds for every *ImportError with
+// CodeMissingFile in the chain of err.
+var (
+	ErrMissingFile        = &ImportError{Code: CodeMissingFile}
+	ErrBadTestFilename    = &ImportError{Code: CodeBadTestFilename}
+	ErrGroupMismatch      = &ImportError{Code: CodeGroupMismatch}
+	ErrLimitExceeded      = &ImportError{Code: CodeLimitExceeded}
+	ErrInvalidSyntax      = &ImportError{Code: CodeInvalidSyntax}
+	ErrEmptyAnswer        = &ImportError{Code: CodeEmptyAnswer}
+	ErrConflictingAnswers = &ImportError{Code: CodeConflictingAnswers}
+	ErrUnknownTag         = &ImportError{Code: CodeUnknownTag}
+)
+
+// ImportError is an error in the task being imported, as opposed to a
+// failure of the importer itself.
+type ImportError struct {
+	Code ErrorCode
+	// File is the path of the file the error is in, relative to the task
+	// directory, or "" if the error is not about a single file.
+	File string
+	// Line is the line of File the error is on, 0 if unknown.
+	Line int
+	// Err describes the error and wraps its cause, if any.
+	Err error
+}
+
+// importErrorf returns an *ImportError whose Err is fmt.Errorf(format, a...).
+func importErrorf(code ErrorCode, file string, line int, format string, a ...interface{}) *ImportError {
+	return &ImportError{Code: code, File: file, Line: line, Err: fmt.Errorf(format, a...)}
+}
+
+func (e *ImportError) Error() string {
+	msg := string(e.Code)
+	if e.Err != nil {
+		msg = e.Err.Error()
+	}
+	if e.File == "" && e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, msg)
+	}
+	if e.File == "" {
+		return msg
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, msg)
+	}
+	return fmt.Sprintf("%s: %s", e.File, msg)
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *ImportError with the same code, see
+// ErrMissingFile.
+func (e *ImportError) Is(target error) bool {
+	t, ok := target.(*ImportError)
+	return ok && t.Code == e.Code
+}
+
+// ErrorCodeOf returns the code of the first *ImportError in the chain of
+// err, "" if there is none.
+func ErrorCodeOf(err error) ErrorCode {
+	importErr := &ImportError{}
+	if errors.As(err, &importErr) {
+		return importErr.Code
+	}
+	return ""
+}
+
+// ErrorList holds errors that are reported at once, such as everything
+// validation found wrong with a task. errors.Is and errors.As look
+// through all of them.
+type ErrorList []error
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (l ErrorList) Unwrap() []error {
+	return l
+}
+
+// Err returns the list as an error, or nil if it is empty.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+var yamlErrorLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+// yamlErrorLine returns the line a gopkg.in/yaml.v2 error is on, 0 if
+// the message does not tell.
+func yamlErrorLine(err error) int {
+	match := yamlErrorLineRegexp.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(match[1])
+	return line
+}
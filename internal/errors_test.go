@@ -0,0 +1,72 @@
+package internal_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lio2024TaskMapFS(t *testing.T) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, content := range lio2024TaskFiles(t) {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return fsys
+}
+
+func TestImportErrors(t *testing.T) {
+	fsys := lio2024TaskMapFS(t)
+	delete(fsys, "task.yaml")
+	_, err := internal.ParseLio2024TaskFS(fsys)
+	assert.ErrorIs(t, err, internal.ErrMissingFile)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	fsys = lio2024TaskMapFS(t)
+	fsys["task.yaml"] = &fstest.MapFile{Data: []byte("name: kp\ntime_limit: fast\n")}
+	_, err = internal.ParseLio2024TaskFS(fsys)
+	importErr := &internal.ImportError{}
+	require.ErrorAs(t, err, &importErr)
+	assert.Equal(t, internal.CodeInvalidSyntax, importErr.Code)
+	assert.Equal(t, "task.yaml", importErr.File)
+	assert.Equal(t, 2, importErr.Line)
+
+	fsys = lio2024TaskMapFS(t)
+	fsys["testi/tests.zip"] = &fstest.MapFile{Data: zipBytes(t, map[string]string{
+		"kp.i00": "1\n", "kp.x00": "2\n",
+	})}
+	_, err = internal.ParseLio2024TaskFS(fsys)
+	require.ErrorAs(t, err, &importErr)
+	assert.Equal(t, internal.CodeBadTestFilename, importErr.Code)
+	assert.Equal(t, "kp.x00", importErr.File)
+
+	fsys = fstest.MapFS{
+		"testi/kp.i1": &fstest.MapFile{Data: []byte("1\n")},
+		"testi/kp.o1": &fstest.MapFile{Data: []byte("1\n")},
+		"punkti.txt":  &fstest.MapFile{Data: []byte("1 100\n2-x 5\n")},
+	}
+	_, _, err = internal.ParseLioLegacyTaskFS(fsys, "kp")
+	require.ErrorAs(t, err, &importErr)
+	assert.Equal(t, "punkti.txt:2: failed to convert x to int: strconv.Atoi: parsing \"x\": invalid syntax", err.Error())
+	assert.Equal(t, internal.CodeInvalidSyntax, internal.ErrorCodeOf(err))
+}
+
+func TestErrorList(t *testing.T) {
+	assert.NoError(t, internal.ErrorList{}.Err())
+
+	err := internal.TaskMetadata{Tags: []string{"dp"}, Difficulty: 6}.Validate(internal.TagVocabulary{})
+	errs := internal.ErrorList{}
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 2)
+	assert.ErrorIs(t, err, internal.ErrLimitExceeded)
+	assert.ErrorIs(t, err, internal.ErrUnknownTag)
+	assert.Equal(t, "difficulty must be between 1 and 5, got 6; tags not in vocabulary: dp", err.Error())
+
+	wrapped := errors.Join(errors.New("other"), err)
+	assert.ErrorIs(t, wrapped, internal.ErrUnknownTag)
+	assert.Equal(t, internal.CodeLimitExceeded, internal.ErrorCodeOf(wrapped))
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+)
+
+// Exporter writes a task in a format other than the programme.lv one.
+type Exporter interface {
+	// Name is the value of the -target flag that selects the exporter.
+	Name() string
+	// Description is a one-line summary shown by the formats command.
+	Description() string
+	// Extension is appended to the output path, empty if the exporter
+	// writes a directory.
+	Extension() string
+	// Export writes the task to destPath, which must not exist yet.
+	Export(task *fstaskparser.Task, destPath string, opts ExportOptions) error
+}
+
+// ExportOptions carries what an exporter may need beyond the task itself.
+type ExportOptions struct {
+	// ShortName is the short identifier of the task, such as "kp".
+	ShortName string
+	// Checker is the checker source followed by its headers, nil if the
+	// task has no checker.
+	Checker []SourceFile
+	// CheckerKind is how the checker is run, see CheckerFinder.
+	CheckerKind string
+	// Validator is the input validator source followed by its headers,
+	// nil if the task has no validator.
+	Validator []SourceFile
+	// Graders are the grader sources followed by their headers, nil if
+	// the task has no graders.
+	Graders []SourceFile
+}
+
+var (
+	// ErrCheckerNotSupported is returned for a checker that the target
+	// format cannot hold or cannot run, see CheckerFinder.CheckerKind.
+	ErrCheckerNotSupported = errors.New("checker not supported by target format")
+	// ErrGradersNotSupported is returned for graders that the target
+	// format cannot hold.
+	ErrGradersNotSupported = errors.New("graders not supported by target format")
+)
+
+var exporters = map[string]Exporter{}
+
+// RegisterExporter makes an exporter available by its name.
+// It panics if an exporter with the same name is already registered.
+func RegisterExporter(exp Exporter) {
+	if _, ok := exporters[exp.Name()]; ok {
+		panic(fmt.Sprintf("exporter %s already registered", exp.Name()))
+	}
+	exporters[exp.Name()] = exp
+}
+
+// Exporters returns all registered exporters sorted by name.
+func Exporters() []Exporter {
+	res := make([]Exporter, 0, len(exporters))
+	for _, exp := range exporters {
+		res = append(res, exp)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name() < res[j].Name()
+	})
+	return res
+}
+
+// GetExporter returns the exporter registered under the name.
+func GetExporter(name string) (Exporter, error) {
+	exp, ok := exporters[name]
+	if !ok {
+		names := []string{}
+		for _, e := range Exporters() {
+			names = append(names, e.Name())
+		}
+		return nil, fmt.Errorf("%w: target format %q, supported targets: %s",
+			ErrUnsupportedFormat, name, strings.Join(names, ", "))
+	}
+	return exp, nil
+}
+
+// testFilename returns the filename of the test without an extension,
+// falling back to its zero-padded ID like fstaskparser does.
+func testFilename(task *fstaskparser.Task, testID int) string {
+	if fname := task.GetTestFilenameFromID(testID); fname != "" {
+		return fname
+	}
+	return fmt.Sprintf("%03d", testID)
+}
@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
+)
+
+// ArchiveFS returns the files of a zip or gzip-compressed tar archive held
+// in memory as an fs.FS. A tar archive is decompressed right away, checking
+// the context between files.
+func ArchiveFS(ctx context.Context, content []byte, format string) (fs.FS, error) {
+	switch format {
+	case ArchiveZip:
+		return zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	case ArchiveTarGz:
+		return tarGzFS(ctx, bytes.NewReader(content))
+	}
+	return nil, fmt.Errorf("unsupported archive format %q, supported formats: %s, %s",
+		format, ArchiveZip, ArchiveTarGz)
+}
+
+// tarGzFS reads a gzip-compressed tar archive into memory. Like Untar, it
+// only accepts regular files and directories.
+func tarGzFS(ctx context.Context, r io.Reader) (fs.FS, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	res := fstest.MapFS{}
+	tr := tar.NewReader(gr)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		header, err := tr.Next()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if name == "." {
+			continue
+		}
+		if !fs.ValidPath(name) {
+			return nil, fmt.Errorf("illegal file path: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			res[name] = &fstest.MapFile{Mode: fs.ModeDir | 0755, ModTime: header.ModTime}
+			continue
+		case tar.TypeReg:
+		case tar.TypeXGlobalHeader:
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported entry type of %s", header.Name)
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = &fstest.MapFile{
+			Data:    content,
+			Mode:    header.FileInfo().Mode().Perm(),
+			ModTime: header.ModTime,
+		}
+	}
+}
+
+// TaskRootFS returns the task directory of an archived task. Archives often
+// wrap the task in a single top-level directory, which is then the task
+// directory, see ExtractTaskArchive. The name of the task directory is
+// returned as well, "" if the task is at the root.
+func TaskRootFS(fsys fs.FS) (fs.FS, string, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read archive root: %w", err)
+	}
+	// macOS adds resource forks next to the task when zipping
+	contents := []fs.DirEntry{}
+	for _, entry := range entries {
+		if entry.Name() != "__MACOSX" {
+			contents = append(contents, entry)
+		}
+	}
+	if len(contents) == 1 && contents[0].IsDir() {
+		sub, err := fs.Sub(fsys, contents[0].Name())
+		if err != nil {
+			return nil, "", err
+		}
+		return sub, contents[0].Name(), nil
+	}
+	return fsys, "", nil
+}
+
+// CopyFSToDir writes the regular files of fsys to the directory, creating
+// it if needed. The context is checked between files.
+func CopyFSToDir(ctx context.Context, fsys fs.FS, dest string) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fpath := filepath.Join(dest, filepath.FromSlash(name))
+		if d.IsDir() {
+			return os.MkdirAll(fpath, os.ModePerm)
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("unsupported file type of %s", name)
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(fpath, content, 0644)
+	})
+}
@@ -0,0 +1,258 @@
+package internal_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func zipBytes(t *testing.T, files map[string]string) []byte {
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := bytes.Buffer{}
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+var lio2024TaskYaml = `name: kp
+title: Kvadrātveida putekļsūcējs
+time_limit: 0.5
+memory_limit: 256
+subtask_points: [0, 100]
+tests_archive: ./testi/tests.zip
+checker: riki/checker.cpp
+tests_groups:
+  - groups: 0
+    points: 0
+    public: true
+    subtask: 0
+  - groups: 1
+    points: 100
+    subtask: 1
+`
+
+func lio2024TaskFiles(t *testing.T) map[string]string {
+	return map[string]string{
+		"task.yaml": lio2024TaskYaml,
+		"testi/tests.zip": string(zipBytes(t, map[string]string{
+			"kp.i00": "1\n", "kp.o00": "2\n",
+			"kp.i01a": "3\n", "kp.o01a": "4\n",
+			"kp.i01b": "5\n", "kp.o01b": "6\n",
+		})),
+		"teksts/kp.pdf":    "%PDF",
+		"riki/checker.cpp": "int main() {}\n",
+		"riki/testlib.h":   "#pragma once\n",
+	}
+}
+
+func TestParseLio2024TaskFS(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, content := range lio2024TaskFiles(t) {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+
+	imp, err := internal.DetectImporterFS(fsys, "kp")
+	require.NoError(t, err)
+	assert.Equal(t, "lio2024", imp.Name())
+
+	task, warnings, err := internal.ParseTaskFS(context.Background(), imp, fsys, "kp", internal.ParseOptions{}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, "Kvadrātveida putekļsūcējs", task.GetTaskName())
+	assert.Len(t, task.GetExamples(), 1)
+	assert.Len(t, task.GetTestsSortedByID(), 2)
+	assert.Equal(t, 100, task.GetInfoOnTestGroup(1).Points)
+
+	checker, err := imp.(internal.CheckerFinder).FindChecker(fsys)
+	require.NoError(t, err)
+	require.Len(t, checker, 2)
+	assert.Equal(t, "checker.cpp", checker[0].Filename)
+	assert.Equal(t, "testlib.h", checker[1].Filename)
+}
+
+func TestTaskRootFS(t *testing.T) {
+	files := map[string]string{"__MACOSX/._kp": ""}
+	for name, content := range lio2024TaskFiles(t) {
+		files["kp/"+name] = content
+	}
+
+	archive, err := internal.ArchiveFS(context.Background(), zipBytes(t, files), internal.ArchiveZip)
+	require.NoError(t, err)
+	fsys, name, err := internal.TaskRootFS(archive)
+	require.NoError(t, err)
+	assert.Equal(t, "kp", name)
+
+	_, tests, err := internal.ReadLio2024TaskSourcesFS(fsys)
+	require.NoError(t, err)
+	assert.Len(t, tests, 3)
+}
+
+func TestArchiveFSTarGz(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "kp.tar.gz")
+	writeTarGz(t, archivePath, map[string]string{"./kp/task.yaml": "name: kp\n"})
+	content, err := os.ReadFile(archivePath)
+	require.NoError(t, err)
+
+	archive, err := internal.ArchiveFS(context.Background(), content, internal.ArchiveTarGz)
+	require.NoError(t, err)
+	fsys, name, err := internal.TaskRootFS(archive)
+	require.NoError(t, err)
+	assert.Equal(t, "kp", name)
+	taskYaml, err := fsys.Open("task.yaml")
+	require.NoError(t, err)
+	taskYaml.Close()
+
+	evilPath := filepath.Join(t.TempDir(), "evil.tgz")
+	writeTarGz(t, evilPath, map[string]string{"../evil.txt": "x"})
+	content, err = os.ReadFile(evilPath)
+	require.NoError(t, err)
+	_, err = internal.ArchiveFS(context.Background(), content, internal.ArchiveTarGz)
+	assert.Error(t, err)
+}
+
+func TestReadLioTestsFromArchiveDefaultsToZip(t *testing.T) {
+	fsys := fstest.MapFS{
+		"testi/tests": &fstest.MapFile{Data: zipBytes(t, map[string]string{"kp.i00": "1\n", "kp.o00": "2\n"})},
+	}
+	tests, err := internal.ReadLioTestsFromArchiveFS(fsys, "testi/tests")
+	require.NoError(t, err)
+	assert.Len(t, tests, 1)
+}
+
+func TestParseTaskFSKattis(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, content := range map[string]string{
+		"problem.yaml":                   "type: scoring\n",
+		"data/sample/1.in":               "1\n",
+		"data/sample/1.ans":              "1\n",
+		"data/secret/sub1/testdata.yaml": "range: 0 30\n",
+		"data/secret/sub1/1.in":          "2\n",
+		"data/secret/sub1/1.ans":         "2\n",
+	} {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	imp, err := internal.DetectImporterFS(fsys, "kp")
+	require.NoError(t, err)
+	assert.Equal(t, "kattis", imp.Name())
+
+	// the package is read from fsys, not from a copy on disk
+	_, isFSImporter := imp.(internal.FSImporter)
+	assert.True(t, isFSImporter)
+	task, warnings, err := internal.ParseTaskFS(context.Background(), imp, fsys, "kp", internal.ParseOptions{}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, warnings, `problem.yaml has no lv or en name, using directory name "kp"`)
+	assert.Equal(t, "kp", task.GetTaskName())
+	assert.Len(t, task.GetExamples(), 1)
+	assert.Equal(t, 30, task.GetInfoOnTestGroup(1).Points)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = internal.ParseTaskFS(ctx, imp, fsys, "kp", internal.ParseOptions{}, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestParseTaskFSCopiesForProglv(t *testing.T) {
+	stored, err := fstaskparser.NewTask("Kp")
+	require.NoError(t, err)
+	stored.AddExample([]byte("0\n"), []byte("1\n"))
+	a := stored.AddTest([]byte("1\n"), []byte("2\n"))
+	require.NoError(t, stored.AddTestGroupWithID(1, 100, false, []int{a}, 1))
+	dir := filepath.Join(t.TempDir(), "kp_proglv")
+	require.NoError(t, stored.Store(dir))
+
+	fsys := fstest.MapFS{}
+	require.NoError(t, fs.WalkDir(os.DirFS(dir), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		fsys[name] = &fstest.MapFile{Data: content}
+		return err
+	}))
+	imp, err := internal.DetectImporterFS(fsys, "kp_proglv")
+	require.NoError(t, err)
+	assert.Equal(t, "proglv", imp.Name())
+
+	// fstaskparser only reads from disk, the proglv importer reads a copy
+	task, _, err := internal.ParseTaskFS(context.Background(), imp, fsys, "kp_proglv", internal.ParseOptions{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Kp", task.GetTaskName())
+	assert.Len(t, task.GetTestsSortedByID(), 1)
+}
+
+func TestLio2024UsedFiles(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, content := range lio2024TaskFiles(t) {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	fsys["teksts/kp.typ"] = &fstest.MapFile{Data: []byte("= Kp\n")}
+
+	imp, err := internal.GetImporter("lio2024")
+	require.NoError(t, err)
+	used, err := imp.(internal.UsedFilesLister).UsedFiles(fsys)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"task.yaml", internal.MetadataSidecarFilename, internal.OlympiadConfigFilename,
+		"testi/tests.zip", "teksts/kp.pdf", "riki/checker.cpp", "riki/testlib.h",
+	}, used)
+}
+
+func TestParseTaskFSProgress(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, content := range lio2024TaskFiles(t) {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	imp, err := internal.DetectImporterFS(fsys, "kp")
+	require.NoError(t, err)
+
+	events := []internal.ProgressEvent{}
+	timings := &internal.StageTimings{Progress: func(e internal.ProgressEvent) {
+		events = append(events, e)
+	}}
+	_, _, err = internal.ParseTaskFS(context.Background(), imp, fsys, "kp", internal.ParseOptions{}, timings)
+	require.NoError(t, err)
+
+	testsRead := []internal.ProgressEvent{}
+	started := []string{}
+	for _, e := range events {
+		switch e.Kind {
+		case internal.ProgressTestsRead:
+			testsRead = append(testsRead, e)
+		case internal.ProgressStageStarted:
+			started = append(started, e.Stage)
+		}
+	}
+	require.Len(t, testsRead, 3)
+	assert.Equal(t, 3, testsRead[2].TestsDone)
+	assert.Equal(t, 3, testsRead[2].TestsTotal)
+	assert.Equal(t, int64(12), testsRead[2].Bytes)
+	assert.Contains(t, started, "yaml parse")
+	assert.Contains(t, started, "test read")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = internal.ParseTaskFS(ctx, imp, fsys, "kp", internal.ParseOptions{}, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,296 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// generatorTimeout bounds a single run of a generator or of the solution.
+const generatorTimeout = 5 * time.Minute
+
+// GeneratorPlanEntry is a line of a generator plan: the test it makes and
+// the generator making it.
+type GeneratorPlanEntry struct {
+	// Line is the line of the plan the entry is on.
+	Line          int
+	TestGroup     int
+	NoInTestGroup int
+	// Program is the path of the generator relative to the plan. Files
+	// that are not C, C++ or Python sources are copied as the input.
+	Program string
+	Args    []string
+}
+
+var generatorPlanTestRegexp = regexp.MustCompile(`^(\d+)([a-z]?)$`)
+
+// ParseGeneratorPlan parses a generator plan. Every line names a test like
+// the LIO test files do, e.g. 01a or 00, followed by the generator and its
+// arguments, which are not passed through a shell:
+//
+//	# examples are written by hand
+//	00 ex00.txt
+//	01a gen_small.py 10 1
+//	01b gen_small.py 10 2
+//
+// Empty lines and lines starting with # are skipped. name is the path of
+// the plan used in errors.
+func ParseGeneratorPlan(content []byte, name string) ([]GeneratorPlanEntry, error) {
+	res := []GeneratorPlanEntry{}
+	seen := map[[2]int]int{}
+	for i, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, importErrorf(CodeInvalidSyntax, name, i+1, "expected a test and a generator, got %q", strings.TrimSpace(line))
+		}
+
+		match := generatorPlanTestRegexp.FindStringSubmatch(fields[0])
+		if match == nil {
+			return nil, importErrorf(CodeBadTestFilename, name, i+1, "unexpected test %q, expected e.g. 01a", fields[0])
+		}
+		group, _ := strconv.Atoi(match[1])
+		no := 1
+		if match[2] != "" {
+			no = int(match[2][0]) - int('a') + 1
+		}
+		// 01, 1a and 01a all name the first test of group 1
+		key := [2]int{group, no}
+		if prev, ok := seen[key]; ok {
+			return nil, importErrorf(CodeInvalidSyntax, name, i+1, "test %s is already generated on line %d", fields[0], prev)
+		}
+		seen[key] = i + 1
+		res = append(res, GeneratorPlanEntry{
+			Line:          i + 1,
+			TestGroup:     group,
+			NoInTestGroup: no,
+			Program:       fields[1],
+			Args:          fields[2:],
+		})
+	}
+	return res, nil
+}
+
+// generatorPlanFiles returns the paths of fsys of the generators and the
+// inputs that the plan at planPath names, each once.
+func generatorPlanFiles(fsys fs.FS, planPath string) ([]string, error) {
+	content, err := fs.ReadFile(fsys, planPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", planPath, err)
+	}
+	plan, err := ParseGeneratorPlan(content, planPath)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+	seen := map[string]bool{}
+	for _, entry := range plan {
+		programPath := path.Join(path.Dir(planPath), entry.Program)
+		if !seen[programPath] {
+			seen[programPath] = true
+			res = append(res, programPath)
+		}
+	}
+	return res, nil
+}
+
+// isProgramSource tells whether CompileProgram can build the file.
+func isProgramSource(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".cpp", ".cc", ".cxx", ".c", ".py":
+		return true
+	}
+	return false
+}
+
+// GenerateLioTests runs the generator plan at planPath of fsys and the
+// solution at solutionPath on every generated input to produce the
+// answers. The tests are returned sorted by group and number in group,
+// like readLio2024Tests does, and named taskName.
+func GenerateLioTests(ctx context.Context, fsys fs.FS, planPath string, solutionPath string, taskName string, timings *StageTimings) ([]LioTest, error) {
+	content, err := fs.ReadFile(fsys, planPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, importErrorf(CodeMissingFile, planPath, 0, "generator plan not found: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", planPath, err)
+	}
+	plan, err := ParseGeneratorPlan(content, planPath)
+	if err != nil {
+		return nil, err
+	}
+
+	done := timings.Start("compile generators")
+	programs, err := compileGeneratorPlan(ctx, fsys, plan, planPath, solutionPath)
+	done()
+	defer func() {
+		for _, p := range programs {
+			p.Close()
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+	solution := programs[solutionPath]
+
+	done = timings.Start("generate")
+	defer done()
+
+	res := []LioTest{}
+	var bytesGenerated int64
+	for i, entry := range plan {
+		label := lioTestLabel(LioTest{TestGroup: entry.TestGroup, NoInTestGroup: entry.NoInTestGroup})
+
+		programPath := path.Join(path.Dir(planPath), entry.Program)
+		var input []byte
+		if program, ok := programs[programPath]; ok {
+			input, err = runGeneratorStep(ctx, program, entry.Args, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate test %s with %s: %w", label, entry.Program, err)
+			}
+		} else {
+			input, err = fs.ReadFile(fsys, programPath)
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, importErrorf(CodeMissingFile, planPath, entry.Line, "input of test %s not found: %w", label, err)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", programPath, err)
+			}
+		}
+
+		answer, err := runGeneratorStep(ctx, solution, nil, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to answer test %s: %w", label, err)
+		}
+
+		res = append(res, LioTest{
+			TaskName:      taskName,
+			TestGroup:     entry.TestGroup,
+			NoInTestGroup: entry.NoInTestGroup,
+			Input:         input,
+			Answer:        answer,
+		})
+		bytesGenerated += int64(len(input) + len(answer))
+		timings.TestsRead(i+1, len(plan), bytesGenerated)
+	}
+	timings.logger().Debug("tests generated", "plan", planPath, "tests", len(res), "bytes", bytesGenerated)
+
+	// number the tests like readLioTestFiles does, by input filename
+	sort.Slice(res, func(i, j int) bool {
+		return lioTestFilename(res[i], "i") < lioTestFilename(res[j], "i")
+	})
+	for i := range res {
+		res[i].NoInLexFnameOrder = i
+	}
+	sortLioTests(res)
+	return res, nil
+}
+
+// compileGeneratorPlan compiles the generators of the plan and the
+// solution, keyed by their paths of fsys. The programs compiled before an
+// error are returned along with it, for the caller to close.
+func compileGeneratorPlan(ctx context.Context, fsys fs.FS, plan []GeneratorPlanEntry, planPath string, solutionPath string) (map[string]*Program, error) {
+	programs := map[string]*Program{}
+	compile := func(programPath string) error {
+		files, err := readSourceFileWithHeaders(fsys, programPath)
+		if err != nil {
+			return err
+		}
+		p, err := CompileProgram(ctx, files)
+		if err != nil {
+			return err
+		}
+		programs[programPath] = p
+		return nil
+	}
+
+	for _, entry := range plan {
+		programPath := path.Join(path.Dir(planPath), entry.Program)
+		if _, ok := programs[programPath]; ok || !isProgramSource(programPath) {
+			continue
+		}
+		err := compile(programPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			return programs, importErrorf(CodeMissingFile, planPath, entry.Line, "generator not found: %w", err)
+		}
+		if err != nil {
+			return programs, err
+		}
+	}
+
+	err := compile(solutionPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return programs, importErrorf(CodeMissingFile, solutionPath, 0, "solution not found: %w", err)
+	}
+	return programs, err
+}
+
+// runGeneratorStep runs a generator or the solution, adding what it wrote
+// to stderr to the error if it fails.
+func runGeneratorStep(ctx context.Context, p *Program, args []string, input []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, generatorTimeout)
+	defer cancel()
+
+	output, err := p.Run(ctx, args, input)
+	exitErr := &exec.ExitError{}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out after %s", generatorTimeout)
+	}
+	if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+		return nil, fmt.Errorf("%w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+	}
+	return output, err
+}
+
+// lioTestFilename names the input ("i") or answer ("o") file of the test
+// like writeLioTest does, e.g. kp.i01a.
+func lioTestFilename(t LioTest, kind string) string {
+	return fmt.Sprintf("%s.%s%02d%s", t.TaskName, kind, t.TestGroup, string(rune(t.NoInTestGroup+int('a')-1)))
+}
+
+// CompareLioTests compares generated tests with the ones shipped in the
+// named archive and describes every difference, nil if there is none.
+func CompareLioTests(generated []LioTest, shipped []LioTest, archiveName string) []string {
+	byLabel := map[string]LioTest{}
+	for _, t := range shipped {
+		byLabel[lioTestLabel(t)] = t
+	}
+
+	res := []string{}
+	for _, t := range generated {
+		label := lioTestLabel(t)
+		s, ok := byLabel[label]
+		if !ok {
+			res = append(res, fmt.Sprintf("test %s is generated but missing from %s", label, archiveName))
+			continue
+		}
+		delete(byLabel, label)
+		if !bytes.Equal(t.Input, s.Input) {
+			res = append(res, fmt.Sprintf("generated input of test %s differs from %s", label, archiveName))
+		}
+		if !bytes.Equal(t.Answer, s.Answer) {
+			res = append(res, fmt.Sprintf("generated answer of test %s differs from %s", label, archiveName))
+		}
+	}
+	for _, s := range shipped {
+		if _, ok := byLabel[lioTestLabel(s)]; ok {
+			res = append(res, fmt.Sprintf("test %s of %s is not generated", lioTestLabel(s), archiveName))
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
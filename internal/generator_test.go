@@ -0,0 +1,89 @@
+package internal_test
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGeneratorPlan(t *testing.T) {
+	plan, err := internal.ParseGeneratorPlan([]byte("# examples\n00 ex00.txt\n\n01b gen.py 10 --seed 2\n"), "gen/plan.txt")
+	require.NoError(t, err)
+	assert.Equal(t, []internal.GeneratorPlanEntry{
+		{Line: 2, TestGroup: 0, NoInTestGroup: 1, Program: "ex00.txt", Args: []string{}},
+		{Line: 4, TestGroup: 1, NoInTestGroup: 2, Program: "gen.py", Args: []string{"10", "--seed", "2"}},
+	}, plan)
+
+	_, err = internal.ParseGeneratorPlan([]byte("01a gen.py\n1A gen.py\n"), "gen/plan.txt")
+	assert.ErrorIs(t, err, internal.ErrBadTestFilename)
+	assert.EqualError(t, err, `gen/plan.txt:2: unexpected test "1A", expected e.g. 01a`)
+
+	_, err = internal.ParseGeneratorPlan([]byte("01a gen.py\n01a gen.py 2\n"), "gen/plan.txt")
+	assert.EqualError(t, err, "gen/plan.txt:2: test 01a is already generated on line 1")
+	_, err = internal.ParseGeneratorPlan([]byte("01a gen.py\n1a gen.py 2\n"), "gen/plan.txt")
+	assert.EqualError(t, err, "gen/plan.txt:2: test 1a is already generated on line 1")
+	_, err = internal.ParseGeneratorPlan([]byte("01 gen.py\n\n01a gen.py 2\n"), "gen/plan.txt")
+	assert.EqualError(t, err, "gen/plan.txt:3: test 01a is already generated on line 1")
+}
+
+func TestParseTaskFSGenerateTests(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+
+	fsys := lio2024TaskMapFS(t)
+	taskYaml := string(fsys["task.yaml"].Data) + "generator_plan: ./gen/plan.txt\nsolution: ./risin/kp.py\n"
+	fsys["task.yaml"] = &fstest.MapFile{Data: []byte(taskYaml)}
+	fsys["gen/plan.txt"] = &fstest.MapFile{Data: []byte("00 ex00.txt\n01a gen.py 3\n01b gen.py 7\n")}
+	fsys["gen/ex00.txt"] = &fstest.MapFile{Data: []byte("1\n")}
+	fsys["gen/gen.py"] = &fstest.MapFile{Data: []byte("import sys\nprint(sys.argv[1])\n")}
+	fsys["risin/kp.py"] = &fstest.MapFile{Data: []byte("print(int(input()) + 1)\n")}
+	imp, err := internal.GetImporter("lio2024")
+	require.NoError(t, err)
+
+	// the shipped 01b is 5, the plan generates 7
+	task, warnings, err := internal.ParseTaskFS(context.Background(), imp, fsys, "kp",
+		internal.ParseOptions{GenerateTests: true}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"generated input of test 1b differs from tests.zip",
+		"generated answer of test 1b differs from tests.zip",
+	}, warnings)
+	tests := task.GetTestsSortedByID()
+	require.Len(t, tests, 2)
+	assert.Equal(t, "7\n", string(tests[1].Input))
+	assert.Equal(t, "8\n", string(tests[1].Answer))
+
+	// without an archive the tests can only be generated
+	fsys["task.yaml"] = &fstest.MapFile{Data: []byte(strings.Replace(taskYaml, "tests_archive: ./testi/tests.zip\n", "", 1))}
+	delete(fsys, "testi/tests.zip")
+	assert.True(t, imp.Detect(fsys))
+	_, warnings, err = internal.ParseTaskFS(context.Background(), imp, fsys, "kp",
+		internal.ParseOptions{GenerateTests: true}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	// the sources are the generated tests, e.g. for the HTML report
+	_, sources, _, err := internal.ParseLio2024TaskWithSources(context.Background(), fsys,
+		internal.ParseOptions{GenerateTests: true}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "kp", sources.Yaml.TaskShortIDCode)
+	require.Len(t, sources.Tests, 3)
+	assert.Equal(t, "7\n", string(sources.Tests[2].Input))
+
+	// validate reads the tests without building a task of them
+	sources, _, err = internal.ImportLio2024Sources(context.Background(), fsys,
+		internal.ParseOptions{GenerateTests: true}, nil)
+	require.NoError(t, err)
+	require.Len(t, sources.Tests, 3)
+	assert.Equal(t, "8\n", string(sources.Tests[2].Answer))
+
+	_, _, err = internal.ParseTaskFS(context.Background(), imp, fsys, "kp", internal.ParseOptions{}, nil)
+	assert.ErrorIs(t, err, internal.ErrMissingFile)
+}
@@ -0,0 +1,25 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// StoreGraders writes the grader sources and their headers to the grader
+// directory of a stored task. Like validators, graders have no place in
+// the fs task format, readers of it ignore the directory.
+func StoreGraders(taskDirPath string, files []SourceFile) error {
+	graderDir := filepath.Join(taskDirPath, "grader")
+	err := os.MkdirAll(graderDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create grader directory: %w", err)
+	}
+	for _, f := range files {
+		err = os.WriteFile(filepath.Join(graderDir, f.Filename), f.Content, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write grader: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package internal
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Lio2024HtmlReport is what the jury review report of a LIO 2024 task is
+// rendered from.
+type Lio2024HtmlReport struct {
+	Yaml  ParsedLio2024Yaml
+	Tests []LioTest
+	// StatementHref links the statement PDF, relative to the report.
+	StatementHref string
+	// Warnings are shown along with the ones found by CheckLio2024Task.
+	Warnings []string
+}
+
+// Largest limits a task may ask for, see CheckLio2024Task.
+const (
+	MaxCPUTimeLimitSeconds  = 10.0
+	MaxMemoryLimitMegabytes = 2048
+)
+
+// CheckLio2024Task looks for inconsistencies between the task.yaml and
+// the tests that the importer lets through, for tests with the same input
+// but different answers and for limits out of range.
+func CheckLio2024Task(parsedYaml ParsedLio2024Yaml, tests []LioTest) ErrorList {
+	res := ErrorList{}
+
+	if parsedYaml.CpuTimeLimitInSeconds <= 0 || parsedYaml.CpuTimeLimitInSeconds > MaxCPUTimeLimitSeconds {
+		res = append(res, importErrorf(CodeLimitExceeded, "task.yaml", 0,
+			"time_limit must be between 0 and %g seconds, got %g", MaxCPUTimeLimitSeconds, parsedYaml.CpuTimeLimitInSeconds))
+	}
+	if parsedYaml.MemoryLimitInMegabytes <= 0 || parsedYaml.MemoryLimitInMegabytes > MaxMemoryLimitMegabytes {
+		res = append(res, importErrorf(CodeLimitExceeded, "task.yaml", 0,
+			"memory_limit must be between 0 and %d MB, got %d", MaxMemoryLimitMegabytes, parsedYaml.MemoryLimitInMegabytes))
+	}
+
+	testCount := map[int]int{}
+	for _, t := range tests {
+		testCount[t.TestGroup]++
+		if len(t.Answer) == 0 {
+			res = append(res, importErrorf(CodeEmptyAnswer, "", 0, "test %s has an empty answer", lioTestLabel(t)))
+		}
+	}
+
+	inYaml := map[int]bool{}
+	subtaskPoints := map[int]int{}
+	for _, g := range parsedYaml.TestGroups {
+		inYaml[g.GroupID] = true
+		subtaskPoints[g.Subtask] += g.Points
+		if testCount[g.GroupID] == 0 {
+			res = append(res, importErrorf(CodeGroupMismatch, "task.yaml", 0, "group %d has no tests", g.GroupID))
+		}
+	}
+
+	groups := []int{}
+	for group := range testCount {
+		// examples need no group entry
+		if group != 0 && !inYaml[group] {
+			groups = append(groups, group)
+		}
+	}
+	sort.Ints(groups)
+	for _, group := range groups {
+		res = append(res, importErrorf(CodeGroupMismatch, "task.yaml", 0,
+			"tests of group %d are not in any tests_groups entry", group))
+	}
+
+	for _, d := range FindDuplicateLioTests(tests) {
+		if d.Conflict {
+			res = append(res, importErrorf(CodeConflictingAnswers, "", 0, "%s", d))
+		}
+	}
+
+	for subtask, points := range parsedYaml.SubtaskPoints {
+		if subtaskPoints[subtask] != points {
+			res = append(res, importErrorf(CodeGroupMismatch, "task.yaml", 0,
+				"subtask %d is worth %d points in subtask_points but its groups sum to %d",
+				subtask, points, subtaskPoints[subtask]))
+		}
+	}
+
+	return res
+}
+
+type htmlReportGroup struct {
+	ParsedLio2024YamlTestGroup
+	Tests     int
+	Bytes     int
+	CommentOr string
+}
+
+type htmlReportExample struct {
+	Name   string
+	Input  string
+	Answer string
+}
+
+type htmlReportBucket struct {
+	Label   string
+	Count   int
+	Percent int
+}
+
+// testSizeBuckets are the upper bounds of the test size histogram buckets.
+var testSizeBuckets = []struct {
+	label string
+	max   int
+}{
+	{"< 1 KB", 1 << 10},
+	{"< 10 KB", 10 << 10},
+	{"< 100 KB", 100 << 10},
+	{"< 1 MB", 1 << 20},
+	{"< 10 MB", 10 << 20},
+	{"≥ 10 MB", int(^uint(0) >> 1)},
+}
+
+// maxExamplePreview is how much of an example is shown inline.
+const maxExamplePreview = 4 << 10
+
+// WriteHtml renders the report as a self-contained HTML page.
+func (r Lio2024HtmlReport) WriteHtml(w io.Writer) error {
+	testCount := map[int]int{}
+	testBytes := map[int]int{}
+	examples := []htmlReportExample{}
+	buckets := make([]htmlReportBucket, len(testSizeBuckets))
+	for i, b := range testSizeBuckets {
+		buckets[i].Label = b.label
+	}
+	maxBucket := 0
+	for _, t := range r.Tests {
+		size := len(t.Input) + len(t.Answer)
+		testCount[t.TestGroup]++
+		testBytes[t.TestGroup] += size
+		if t.TestGroup == 0 {
+			examples = append(examples, htmlReportExample{
+				Name:   fmt.Sprintf("%s.i%02d%s", t.TaskName, t.TestGroup, string(rune(t.NoInTestGroup+int('a')-1))),
+				Input:  previewText(t.Input),
+				Answer: previewText(t.Answer),
+			})
+			continue
+		}
+		for i, b := range testSizeBuckets {
+			if size < b.max {
+				buckets[i].Count++
+				if buckets[i].Count > maxBucket {
+					maxBucket = buckets[i].Count
+				}
+				break
+			}
+		}
+	}
+	for i := range buckets {
+		if maxBucket > 0 {
+			buckets[i].Percent = buckets[i].Count * 100 / maxBucket
+		}
+	}
+
+	groups := []htmlReportGroup{}
+	totalPoints := 0
+	for _, g := range r.Yaml.TestGroups {
+		comment := ""
+		if g.Comment != nil {
+			comment = *g.Comment
+		}
+		groups = append(groups, htmlReportGroup{
+			ParsedLio2024YamlTestGroup: g,
+			Tests:                      testCount[g.GroupID],
+			Bytes:                      testBytes[g.GroupID],
+			CommentOr:                  comment,
+		})
+		totalPoints += g.Points
+	}
+
+	warnings := append([]string{}, r.Warnings...)
+	for _, err := range CheckLio2024Task(r.Yaml, r.Tests) {
+		// conflicting answers are among the import warnings as well
+		if !slices.Contains(warnings, err.Error()) {
+			warnings = append(warnings, err.Error())
+		}
+	}
+
+	return htmlReportTemplate.Execute(w, map[string]interface{}{
+		"Yaml":          r.Yaml,
+		"StatementHref": r.StatementHref,
+		"Groups":        groups,
+		"TotalPoints":   totalPoints,
+		"Examples":      examples,
+		"Buckets":       buckets,
+		"Warnings":      warnings,
+	})
+}
+
+func previewText(content []byte) string {
+	if len(content) <= maxExamplePreview {
+		return string(content)
+	}
+	cut := maxExamplePreview
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	return string(content[:cut]) + fmt.Sprintf("\n… (%d bytes more)", len(content)-cut)
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"kb": func(bytes int) string {
+		return fmt.Sprintf("%.1f KB", float64(bytes)/1024)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="lv">
+<head>
+<meta charset="utf-8">
+<title>{{.Yaml.FullTaskName}} ({{.Yaml.TaskShortIDCode}})</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: right; }
+td.text { text-align: left; }
+pre { background: #f4f4f4; padding: 0.5em; overflow-x: auto; }
+.bar { background: #4a7; height: 1em; }
+.warning { color: #a40; }
+</style>
+</head>
+<body>
+<h1>{{.Yaml.FullTaskName}} <small>({{.Yaml.TaskShortIDCode}})</small></h1>
+<p>
+Time limit: {{.Yaml.CpuTimeLimitInSeconds}} s,
+memory limit: {{.Yaml.MemoryLimitInMegabytes}} MB
+{{- if .Yaml.Authors}}<br>Authors: {{join .Yaml.Authors ", "}}{{end}}
+{{- if .Yaml.Tags}}<br>Tags: {{join .Yaml.Tags ", "}}{{end}}
+{{- if .Yaml.Difficulty}}<br>Difficulty: {{.Yaml.Difficulty}}{{end}}
+</p>
+{{if .StatementHref}}<p><a href="{{.StatementHref}}">Statement (PDF)</a></p>{{end}}
+
+<h2>Warnings</h2>
+{{if .Warnings}}<ul>
+{{range .Warnings}}<li class="warning">{{.}}</li>
+{{end}}</ul>{{else}}<p>None.</p>{{end}}
+
+<h2>Test groups</h2>
+<table>
+<tr><th>Group</th><th>Subtask</th><th>Points</th><th>Public</th><th>Tests</th><th>Size</th><th>Comment</th></tr>
+{{range .Groups}}<tr><td>{{.GroupID}}</td><td>{{.Subtask}}</td><td>{{.Points}}</td><td>{{if .Public}}yes{{end}}</td><td>{{.Tests}}</td><td>{{kb .Bytes}}</td><td class="text">{{.CommentOr}}</td></tr>
+{{end}}<tr><th colspan="2">Total</th><th>{{.TotalPoints}}</th><th colspan="4"></th></tr>
+</table>
+{{if .Yaml.SubtaskPoints}}<p>Subtask points: {{range $i, $p := .Yaml.SubtaskPoints}}{{if $i}}, {{end}}{{$i}}: {{$p}}{{end}}</p>{{end}}
+
+<h2>Examples</h2>
+{{range .Examples}}<h3>{{.Name}}</h3>
+<table><tr><th>Input</th><th>Output</th></tr>
+<tr><td class="text"><pre>{{.Input}}</pre></td><td class="text"><pre>{{.Answer}}</pre></td></tr></table>
+{{else}}<p>None.</p>
+{{end}}
+<h2>Test sizes</h2>
+<table>
+{{range .Buckets}}<tr><td class="text">{{.Label}}</td><td>{{.Count}}</td><td class="text" style="width: 20em"><div class="bar" style="width: {{.Percent}}%"></div></td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,90 @@
+package internal_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func htmlReportTask() (internal.ParsedLio2024Yaml, []internal.LioTest) {
+	comment := "Piemēri"
+	parsedYaml := internal.ParsedLio2024Yaml{
+		CpuTimeLimitInSeconds:  0.5,
+		MemoryLimitInMegabytes: 256,
+		FullTaskName:           "Kvadrātveida <putekļsūcējs>",
+		TaskShortIDCode:        "kp",
+		SubtaskPoints:          []int{0, 40, 50},
+		TestGroups: []internal.ParsedLio2024YamlTestGroup{
+			{GroupID: 0, Points: 0, Public: true, Subtask: 0, Comment: &comment},
+			{GroupID: 1, Points: 40, Public: true, Subtask: 1},
+			{GroupID: 2, Points: 60, Public: false, Subtask: 2},
+			{GroupID: 3, Points: 10, Public: false, Subtask: 2},
+		},
+	}
+	tests := []internal.LioTest{
+		{TaskName: "kp", TestGroup: 0, NoInTestGroup: 1, Input: []byte("1 < 2\n"), Answer: []byte("yes\n")},
+		{TaskName: "kp", TestGroup: 1, NoInTestGroup: 1, Input: []byte("2\n"), Answer: []byte("2\n")},
+		{TaskName: "kp", TestGroup: 2, NoInTestGroup: 1, Input: bytes.Repeat([]byte("x"), 2000), Answer: []byte{}},
+		{TaskName: "kp", TestGroup: 4, NoInTestGroup: 1, Input: []byte("4\n"), Answer: []byte("4\n")},
+	}
+	return parsedYaml, tests
+}
+
+func TestCheckLio2024Task(t *testing.T) {
+	parsedYaml, tests := htmlReportTask()
+
+	errs := internal.CheckLio2024Task(parsedYaml, tests)
+	messages := []string{}
+	codes := []internal.ErrorCode{}
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+		codes = append(codes, internal.ErrorCodeOf(err))
+	}
+	assert.Equal(t, []string{
+		"test 2a has an empty answer",
+		"task.yaml: group 3 has no tests",
+		"task.yaml: tests of group 4 are not in any tests_groups entry",
+		"task.yaml: subtask 2 is worth 50 points in subtask_points but its groups sum to 70",
+	}, messages)
+	assert.Equal(t, []internal.ErrorCode{
+		internal.CodeEmptyAnswer,
+		internal.CodeGroupMismatch,
+		internal.CodeGroupMismatch,
+		internal.CodeGroupMismatch,
+	}, codes)
+	assert.ErrorIs(t, errs, internal.ErrGroupMismatch)
+	assert.NotErrorIs(t, errs, internal.ErrLimitExceeded)
+
+	parsedYaml.CpuTimeLimitInSeconds = 20
+	assert.ErrorIs(t, internal.CheckLio2024Task(parsedYaml, tests), internal.ErrLimitExceeded)
+}
+
+func TestLio2024HtmlReport(t *testing.T) {
+	parsedYaml, tests := htmlReportTask()
+
+	buf := bytes.Buffer{}
+	err := internal.Lio2024HtmlReport{
+		Yaml:          parsedYaml,
+		Tests:         tests,
+		StatementHref: "kp_proglv/statements/pdf/lv.pdf",
+		Warnings:      []string{"guessed the memory limit"},
+	}.WriteHtml(&buf)
+	require.NoError(t, err)
+
+	html := buf.String()
+	assert.Contains(t, html, "<h1>Kvadrātveida &lt;putekļsūcējs&gt; <small>(kp)</small></h1>")
+	assert.Contains(t, html, "Time limit: 0.5 s,\nmemory limit: 256 MB")
+	assert.Contains(t, html, `<a href="kp_proglv/statements/pdf/lv.pdf">`)
+	assert.Contains(t, html, "<li class=\"warning\">guessed the memory limit</li>")
+	assert.Contains(t, html, "<li class=\"warning\">task.yaml: group 3 has no tests</li>")
+	assert.Contains(t, html, "<tr><td>0</td><td>0</td><td>0</td><td>yes</td><td>1</td><td>0.0 KB</td><td class=\"text\">Piemēri</td></tr>")
+	assert.Contains(t, html, "<th colspan=\"2\">Total</th><th>110</th>")
+	assert.Contains(t, html, "<h3>kp.i00a</h3>")
+	assert.Contains(t, html, "<pre>1 &lt; 2\n</pre>")
+	// the examples are not part of the histogram
+	assert.Contains(t, html, "<td class=\"text\">&lt; 1 KB</td><td>2</td>")
+	assert.Contains(t, html, "<td class=\"text\">&lt; 10 KB</td><td>1</td>")
+}
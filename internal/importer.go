@@ -0,0 +1,288 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+)
+
+// Importer converts a task directory of some source format
+// into a programme.lv task.
+type Importer interface {
+	// Name is the value of the -format flag that selects the importer.
+	Name() string
+	// Description is a one-line summary shown by the formats command.
+	Description() string
+	// Detect reports whether the task directory, the root of fsys, looks
+	// like a task of this format.
+	Detect(fsys fs.FS) bool
+	// Parse reads the task. The returned warnings describe any guesses
+	// made to fill in information missing from the source.
+	Parse(dirPath string) (*fstaskparser.Task, []string, error)
+}
+
+// CheckerFinder is implemented by importers of formats that may come with
+// a checker. The programme.lv task format cannot hold checkers yet, so the
+// checker is looked up apart from the task and handed to exporters that
+// can store it.
+type CheckerFinder interface {
+	// FindChecker returns the checker source followed by the headers next
+	// to it, or nil if the task has no checker. The task directory is the
+	// root of fsys.
+	FindChecker(fsys fs.FS) ([]SourceFile, error)
+	// CheckerKind is how the checkers of the format are run, one of
+	// CheckerTestlib, CheckerCms and CheckerKattis.
+	CheckerKind() string
+}
+
+// Kinds of checkers, see CheckerFinder. A checker can only be exported to
+// a format that runs checkers of the same kind.
+const (
+	// CheckerTestlib checkers are run as "checker input output answer"
+	// and accept the output with exit code 0, like testlib.h ones.
+	CheckerTestlib = "testlib"
+	// CheckerCms checkers are run as "checker input answer output" and
+	// print the score, from 0 to 1, to stdout.
+	CheckerCms = "cms"
+	// CheckerKattis output validators are run as "validator input answer
+	// feedback_dir" with the output on stdin, and accept it with exit
+	// code 42.
+	CheckerKattis = "kattis"
+)
+
+// ValidatorFinder is implemented by importers of formats that may come
+// with an input validator, a program checking that a test input meets the
+// constraints of the task. Like checkers, validators are kept apart from
+// the task, see CheckerFinder.
+type ValidatorFinder interface {
+	// FindValidator returns the validator source followed by the headers
+	// next to it, or nil if the task has no validator. The task directory
+	// is the root of fsys.
+	FindValidator(fsys fs.FS) ([]SourceFile, error)
+}
+
+// GraderFinder is implemented by importers of formats that may come with
+// graders, sources compiled together with a submission, such as the main
+// function calling the one contestants write. Like checkers, graders are
+// kept apart from the task, see CheckerFinder.
+type GraderFinder interface {
+	// FindGraders returns the grader sources, one per language, followed
+	// by the headers next to them, or nil if the task has no graders. The
+	// task directory is the root of fsys.
+	FindGraders(fsys fs.FS) ([]SourceFile, error)
+}
+
+// FSImporter is implemented by importers that read the task straight from
+// an fs.FS, such as an archive held in memory, and that time the stages of
+// parsing, such as reading the tests, on their own. All importers but
+// proglv are, as fstaskparser only reads tasks from disk; proglv is given
+// a copy of the task on disk, see ParseTaskFS.
+type FSImporter interface {
+	// ParseFS is Parse reading the task directory at the root of fsys.
+	// name stands in for the name of the directory. The context is checked
+	// between files.
+	ParseFS(ctx context.Context, fsys fs.FS, name string, opts ParseOptions, timings *StageTimings) (*fstaskparser.Task, []string, error)
+}
+
+// ParseOptions are the settings of parsing that only the importers of the
+// LioFormats honour, the others ignore them. The zero value parses tasks
+// as they are.
+type ParseOptions struct {
+	// NormalizeTests is one of the NormalizeModes, "" meaning
+	// NormalizeOff, see NormalizeLioTests.
+	NormalizeTests string
+	// GenerateTests runs the generator plan of the task to make the tests
+	// instead of reading the test archive, see GenerateLioTests.
+	GenerateTests bool
+}
+
+// SourceFile is a source file of a checker or another task program.
+type SourceFile struct {
+	Filename string
+	Content  []byte
+}
+
+// programSourceExts are the extensions of the sources of task programs,
+// as opposed to their headers and compiled binaries.
+var programSourceExts = map[string]bool{".cpp": true, ".cc": true, ".cxx": true, ".c": true, ".py": true, ".pas": true, ".java": true}
+
+// readSourceFileWithHeaders reads the source file and the C/C++ headers
+// in the same directory, such as testlib.h.
+func readSourceFileWithHeaders(fsys fs.FS, name string) ([]SourceFile, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path.Base(name), err)
+	}
+	res := []SourceFile{{Filename: path.Base(name), Content: content}}
+
+	headers, err := fs.Glob(fsys, path.Join(path.Dir(name), "*.h"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find headers: %w", err)
+	}
+	sort.Strings(headers)
+	for _, header := range headers {
+		content, err := fs.ReadFile(fsys, header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path.Base(header), err)
+		}
+		res = append(res, SourceFile{Filename: path.Base(header), Content: content})
+	}
+
+	return res, nil
+}
+
+var (
+	// ErrUnsupportedFormat is returned for a source or target format
+	// that is not registered.
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	// ErrFormatNotDetected is returned when no importer or more than one
+	// of them recognise a task directory.
+	ErrFormatNotDetected = errors.New("format not detected")
+)
+
+var importers = map[string]Importer{}
+
+// RegisterImporter makes an importer available by its name.
+// It panics if an importer with the same name is already registered.
+func RegisterImporter(imp Importer) {
+	if _, ok := importers[imp.Name()]; ok {
+		panic(fmt.Sprintf("importer %s already registered", imp.Name()))
+	}
+	importers[imp.Name()] = imp
+}
+
+// Importers returns all registered importers sorted by name.
+func Importers() []Importer {
+	res := make([]Importer, 0, len(importers))
+	for _, imp := range importers {
+		res = append(res, imp)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name() < res[j].Name()
+	})
+	return res
+}
+
+// GetImporter returns the importer registered under the name.
+func GetImporter(name string) (Importer, error) {
+	imp, ok := importers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: source format %q, supported formats: %s",
+			ErrUnsupportedFormat, name, strings.Join(importerNames(Importers()), ", "))
+	}
+	return imp, nil
+}
+
+// DetectImporter returns the only importer whose detector matches the
+// directory. It is an error if none or more than one of them match.
+func DetectImporter(dirPath string) (Importer, error) {
+	return DetectImporterFS(os.DirFS(dirPath), dirPath)
+}
+
+// DetectImporterFS is DetectImporter for the task directory at the root of
+// fsys. name stands in for the directory in errors.
+func DetectImporterFS(fsys fs.FS, name string) (Importer, error) {
+	matching := []Importer{}
+	for _, imp := range Importers() {
+		if imp.Detect(fsys) {
+			matching = append(matching, imp)
+		}
+	}
+
+	if len(matching) == 0 {
+		return nil, fmt.Errorf("%w: failed to detect the format of %s", ErrFormatNotDetected, name)
+	}
+	if len(matching) > 1 {
+		return nil, fmt.Errorf("%w: ambiguous format of %s, matches: %s",
+			ErrFormatNotDetected, name, strings.Join(importerNames(matching), ", "))
+	}
+
+	return matching[0], nil
+}
+
+// ParseTaskFS parses the task directory at the root of fsys. Importers
+// that are not FSImporters, only proglv, parse a copy of it written to a
+// temporary directory; the context is only checked while copying.
+func ParseTaskFS(ctx context.Context, imp Importer, fsys fs.FS, name string, opts ParseOptions, timings *StageTimings) (*fstaskparser.Task, []string, error) {
+	timings.logger().Debug("parsing task", "format", imp.Name(), "name", name)
+	if fsImp, ok := imp.(FSImporter); ok {
+		return fsImp.ParseFS(ctx, fsys, name, opts, timings)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "lio-task-fs")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create tmp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// the directory name is part of the task for some formats
+	dirPath := filepath.Join(tmpDir, filepath.Base(name))
+	timings.logger().Debug("copying task to parse it from disk", "dir", dirPath)
+	done := timings.Start("copy")
+	err = CopyFSToDir(ctx, fsys, dirPath)
+	done()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to copy task to %s: %w", dirPath, err)
+	}
+
+	done = timings.Start("parse")
+	defer done()
+	return imp.Parse(dirPath)
+}
+
+// OpenTaskSource returns the task directory of the source and the
+// importer for it, detecting the format if it is "auto". Archived sources
+// are extracted to a temporary directory, which cleanup removes.
+func OpenTaskSource(sourcePath string, format string) (taskDir string, importer Importer, cleanup func(), err error) {
+	taskDir = sourcePath
+	cleanup = func() {}
+	if ArchiveFormatFromPath(sourcePath) != "" {
+		tmpDir, err := os.MkdirTemp("", "lio-task-source")
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("failed to create tmp directory: %w", err)
+		}
+		cleanup = func() { os.RemoveAll(tmpDir) }
+
+		taskDir, err = ExtractTaskArchive(sourcePath, tmpDir)
+		if err != nil {
+			cleanup()
+			return "", nil, nil, err
+		}
+	}
+
+	if format == "auto" {
+		importer, err = DetectImporter(taskDir)
+	} else {
+		importer, err = GetImporter(format)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+
+	return taskDir, importer, cleanup, nil
+}
+
+// TaskShortName returns the short identifier of the task at the source
+// path, such as "kp". A previous import of the task is named like the
+// original.
+func TaskShortName(sourcePath string) string {
+	baseName := filepath.Base(TrimArchiveExtension(sourcePath))
+	return strings.TrimSuffix(baseName, "_proglv")
+}
+
+func importerNames(imps []Importer) []string {
+	res := make([]string, 0, len(imps))
+	for _, imp := range imps {
+		res = append(res, imp.Name())
+	}
+	return res
+}
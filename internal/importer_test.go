@@ -0,0 +1,53 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectImporter(t *testing.T) {
+	lio2024Dir := t.TempDir()
+	taskYaml := "name: kp\ntitle: Kp\ntests_archive: ./testi/tests.zip\n"
+	require.NoError(t, os.WriteFile(filepath.Join(lio2024Dir, "task.yaml"), []byte(taskYaml), 0644))
+
+	legacyDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(legacyDir, "testi"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(legacyDir, "testi", "kp.i01"), []byte("1\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(legacyDir, "testi", "kp.o01"), []byte("1\n"), 0644))
+
+	imp, err := internal.DetectImporter(lio2024Dir)
+	require.NoError(t, err)
+	assert.Equal(t, "lio2024", imp.Name())
+
+	imp, err = internal.DetectImporter(legacyDir)
+	require.NoError(t, err)
+	assert.Equal(t, "lio-legacy", imp.Name())
+
+	_, err = internal.DetectImporter(t.TempDir())
+	assert.Error(t, err)
+
+	_, err = internal.GetImporter("unknown")
+	assert.Error(t, err)
+}
+
+func TestLio2024ImporterParseWarnings(t *testing.T) {
+	files := lio2024TaskFiles(t)
+	files["testi/tests.zip"] = string(zipBytes(t, map[string]string{
+		"kp.i00": "1\n", "kp.o00": "2\n",
+		"kp.i01a": "3\n", "kp.o01a": "4\n",
+		"kp.i01b": "3\n", "kp.o01b": "4\n",
+	}))
+	dir := t.TempDir()
+	writeFiles(t, dir, files)
+
+	imp, err := internal.GetImporter("lio2024")
+	require.NoError(t, err)
+	_, warnings, err := imp.Parse(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tests 1a, 1b are identical"}, warnings)
+}
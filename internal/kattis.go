@@ -0,0 +1,505 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	RegisterImporter(kattisImporter{})
+	RegisterExporter(kattisExporter{})
+}
+
+type kattisImporter struct{}
+
+func (kattisImporter) Name() string { return "kattis" }
+
+func (kattisImporter) Description() string {
+	return "Kattis/ICPC problem package with problem.yaml and data/sample, data/secret"
+}
+
+func (kattisImporter) Detect(fsys fs.FS) bool {
+	if _, err := fs.Stat(fsys, "problem.yaml"); err != nil {
+		return false
+	}
+	_, err := fs.Stat(fsys, "data")
+	return err == nil
+}
+
+func (kattisImporter) Parse(dirPath string) (*fstaskparser.Task, []string, error) {
+	return ParseKattisPackageDir(dirPath)
+}
+
+func (kattisImporter) ParseFS(ctx context.Context, fsys fs.FS, name string, _ ParseOptions, timings *StageTimings) (*fstaskparser.Task, []string, error) {
+	return parseKattisPackageFS(ctx, fsys, name, timings)
+}
+
+// FindChecker returns the output validator of a package with custom
+// validation. The validator is a single source file in output_validators/
+// or a directory there holding one source and its headers.
+func (kattisImporter) FindChecker(fsys fs.FS) ([]SourceFile, error) {
+	content, err := fs.ReadFile(fsys, "problem.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read problem.yaml: %w", err)
+	}
+	parsedYaml, err := ParseKattisYaml(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse problem.yaml: %w", err)
+	}
+	if !parsedYaml.CustomValidation {
+		return nil, nil
+	}
+
+	entries, err := fs.ReadDir(fsys, "output_validators")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output_validators/: %w", err)
+	}
+	if len(entries) != 1 {
+		return nil, fmt.Errorf("expected a single output validator in output_validators/, found %d", len(entries))
+	}
+
+	validatorPath := path.Join("output_validators", entries[0].Name())
+	if !entries[0].IsDir() {
+		return readSourceFileWithHeaders(fsys, validatorPath)
+	}
+
+	files, err := fs.ReadDir(fsys, validatorPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", validatorPath, err)
+	}
+	sources := []string{}
+	for _, f := range files {
+		if !f.IsDir() && programSourceExts[path.Ext(f.Name())] {
+			sources = append(sources, f.Name())
+		}
+	}
+	if len(sources) != 1 {
+		return nil, fmt.Errorf("expected a single source in %s, found %d", validatorPath, len(sources))
+	}
+	return readSourceFileWithHeaders(fsys, path.Join(validatorPath, sources[0]))
+}
+
+func (kattisImporter) CheckerKind() string { return CheckerKattis }
+
+type ParsedKattisYaml struct {
+	// Names maps a language code to the name in that language.
+	Names                  map[string]string
+	Scoring                bool
+	CustomValidation       bool
+	Interactive            bool
+	CpuTimeLimitInSeconds  float64 // 0 if not specified
+	MemoryLimitInMegabytes int     // 0 if not specified
+	Authors                []string
+	Source                 string
+	Keywords               []string
+}
+
+type kattisRawYaml struct {
+	Name       interface{} `yaml:"name"`
+	Type       string      `yaml:"type"`
+	Validation string      `yaml:"validation"`
+	Author     string      `yaml:"author"`
+	Source     string      `yaml:"source"`
+	Keywords   interface{} `yaml:"keywords"`
+	Limits     struct {
+		TimeLimit float64 `yaml:"time_limit"`
+		Memory    int     `yaml:"memory"`
+	} `yaml:"limits"`
+}
+
+func ParseKattisYaml(content []byte) (res ParsedKattisYaml, err error) {
+	rawYaml := kattisRawYaml{}
+
+	err = yaml.Unmarshal(content, &rawYaml)
+	if err != nil {
+		return
+	}
+
+	res.Names = map[string]string{}
+	switch v := rawYaml.Name.(type) {
+	case nil:
+	case string:
+		res.Names["en"] = v
+	case map[interface{}]interface{}:
+		for lang, name := range v {
+			langStr, ok1 := lang.(string)
+			nameStr, ok2 := name.(string)
+			if !ok1 || !ok2 {
+				err = fmt.Errorf("unsupported name: %+v", v)
+				return
+			}
+			res.Names[langStr] = nameStr
+		}
+	default:
+		err = fmt.Errorf("unsupported name: %+v %T", v, v)
+		return
+	}
+
+	res.Scoring = rawYaml.Type == "scoring"
+	res.CustomValidation = strings.HasPrefix(rawYaml.Validation, "custom")
+	res.Interactive = slices.Contains(strings.Fields(rawYaml.Validation), "interactive")
+	res.CpuTimeLimitInSeconds = rawYaml.Limits.TimeLimit
+	res.MemoryLimitInMegabytes = rawYaml.Limits.Memory
+	res.Source = rawYaml.Source
+
+	for _, author := range strings.FieldsFunc(rawYaml.Author, func(r rune) bool { return r == ',' }) {
+		if author = strings.TrimSpace(author); author != "" {
+			res.Authors = append(res.Authors, author)
+		}
+	}
+
+	switch v := rawYaml.Keywords.(type) {
+	case nil:
+	case string:
+		res.Keywords = strings.Fields(v)
+	default:
+		res.Keywords, err = stringOrStringList(v)
+		if err != nil {
+			err = fmt.Errorf("unsupported keywords: %w", err)
+			return
+		}
+	}
+
+	return
+}
+
+// KattisTestdataYaml holds the grading settings of a test data group.
+type KattisTestdataYaml struct {
+	OnReject    string   `yaml:"on_reject,omitempty"`
+	GraderFlags string   `yaml:"grader_flags,omitempty"`
+	AcceptScore *float64 `yaml:"accept_score,omitempty"`
+	Range       string   `yaml:"range,omitempty"`
+	// InputValidatorFlags are passed to the input validators, see
+	// ValidatorArgs.
+	InputValidatorFlags string `yaml:"input_validator_flags,omitempty"`
+}
+
+// maxScore returns the maximum score of a group: the upper bound of its
+// range or, failing that, its accept score.
+func (t KattisTestdataYaml) maxScore() (int, bool) {
+	if fields := strings.Fields(t.Range); len(fields) == 2 {
+		if v, err := strconv.ParseFloat(fields[1], 64); err == nil {
+			return int(v + 0.5), true
+		}
+	}
+	if t.AcceptScore != nil {
+		return int(*t.AcceptScore + 0.5), true
+	}
+	return 0, false
+}
+
+// ParseKattisPackageDir parses a Kattis problem package. Every directory
+// of data/secret holding test cases becomes an all-or-nothing test group
+// worth the maximum score of its testdata.yaml range. Top-level
+// directories of data/secret are subtasks; the directories below them,
+// however deep, are the groups of that subtask. Test cases directly in
+// data/secret are each scored on their own. The output validator is not
+// part of the task, see FindChecker.
+func ParseKattisPackageDir(dirPath string) (*fstaskparser.Task, []string, error) {
+	return ParseKattisPackageFS(os.DirFS(dirPath), filepath.Base(dirPath))
+}
+
+// ParseKattisPackageFS is ParseKattisPackageDir for the package at the
+// root of fsys. A package without a name is named after dirName.
+func ParseKattisPackageFS(fsys fs.FS, dirName string) (*fstaskparser.Task, []string, error) {
+	return parseKattisPackageFS(context.Background(), fsys, dirName, nil)
+}
+
+func parseKattisPackageFS(ctx context.Context, fsys fs.FS, dirName string, timings *StageTimings) (*fstaskparser.Task, []string, error) {
+	warnings := []string{}
+
+	done := timings.Start("yaml parse")
+	problemYamlContent, err := fs.ReadFile(fsys, "problem.yaml")
+	if err != nil {
+		done()
+		return nil, nil, fmt.Errorf("failed to read problem.yaml: %w", err)
+	}
+
+	parsedYaml, err := ParseKattisYaml(problemYamlContent)
+	done()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse problem.yaml: %w", err)
+	}
+
+	if parsedYaml.Interactive {
+		// TODO: implement
+		return nil, nil, fmt.Errorf("interactors are not implemented yet (found interactive validation)")
+	}
+	if validators, _ := fs.ReadDir(fsys, "output_validators"); len(validators) > 0 && !parsedYaml.CustomValidation {
+		warnings = append(warnings, "output_validators/ is ignored because problem.yaml does not request custom validation")
+	}
+
+	name := ""
+	for _, lang := range []string{"lv", "en"} {
+		if n, ok := parsedYaml.Names[lang]; ok && name == "" {
+			name = n
+		}
+	}
+	if name == "" {
+		name = dirName
+		warnings = append(warnings, fmt.Sprintf("problem.yaml has no lv or en name, using directory name %q", name))
+	}
+
+	task, err := fstaskparser.NewTask(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create new task: %w", err)
+	}
+
+	if parsedYaml.CpuTimeLimitInSeconds == 0 {
+		content, err := fs.ReadFile(fsys, ".timelimit")
+		if err == nil {
+			parsedYaml.CpuTimeLimitInSeconds, err = strconv.ParseFloat(strings.TrimSpace(string(content)), 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse .timelimit: %w", err)
+			}
+		}
+	}
+	if parsedYaml.CpuTimeLimitInSeconds != 0 {
+		task.SetCPUTimeLimitInSeconds(parsedYaml.CpuTimeLimitInSeconds)
+	} else {
+		warnings = append(warnings, fmt.Sprintf("package has no time limit, using default of %.1f s", task.GetCPUTimeLimitInSeconds()))
+	}
+	if parsedYaml.MemoryLimitInMegabytes != 0 {
+		task.SetMemoryLimitInMegabytes(parsedYaml.MemoryLimitInMegabytes)
+	}
+
+	done = timings.Start("test read")
+	samples, err := readKattisTestCases(ctx, fsys, "data/sample")
+	if err != nil {
+		done()
+		return nil, nil, err
+	}
+	for _, s := range samples {
+		task.AddExample(s.input, s.answer)
+	}
+
+	err = addKattisSecretData(ctx, task, fsys, "data/secret", parsedYaml.Scoring, &warnings)
+	done()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	done = timings.Start("statement read")
+	defer done()
+	for _, statementDir := range []string{"problem_statement", "statement"} {
+		pdfFiles, err := fs.Glob(fsys, path.Join(statementDir, "problem.*.pdf"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find PDF files: %w", err)
+		}
+		for _, pdfFile := range pdfFiles {
+			lang := strings.TrimSuffix(strings.TrimPrefix(path.Base(pdfFile), "problem."), ".pdf")
+			pdfBytes, err := fs.ReadFile(fsys, pdfFile)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read PDF file: %w", err)
+			}
+			err = task.AddPDFStatement(lang, pdfBytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to add PDF statement: %w", err)
+			}
+		}
+
+		texFiles, _ := fs.Glob(fsys, path.Join(statementDir, "*.tex"))
+		if len(pdfFiles) == 0 && len(texFiles) > 0 {
+			warnings = append(warnings, fmt.Sprintf("LaTeX statements in %s/ are not imported, only PDF ones are", statementDir))
+		}
+	}
+
+	(TaskMetadata{Authors: parsedYaml.Authors, Tags: parsedYaml.Keywords}).Apply(task)
+	if parsedYaml.Source != "" {
+		task.SetOriginOlympiad(parsedYaml.Source)
+	}
+
+	return task, warnings, nil
+}
+
+type kattisTestCase struct {
+	name   string
+	input  []byte
+	answer []byte
+}
+
+// readKattisTestCases reads the .in/.ans pairs directly in the directory.
+// A missing directory has no test cases. The context is checked before
+// every test case.
+func readKattisTestCases(ctx context.Context, fsys fs.FS, dirPath string) ([]kattisTestCase, error) {
+	inFiles, err := fs.Glob(fsys, path.Join(dirPath, "*.in"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find test cases: %w", err)
+	}
+	sort.Strings(inFiles)
+
+	res := []kattisTestCase{}
+	for _, inFile := range inFiles {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimSuffix(path.Base(inFile), ".in")
+		input, err := fs.ReadFile(fsys, inFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input file: %w", err)
+		}
+		answer, err := fs.ReadFile(fsys, path.Join(dirPath, name+".ans"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read answer file: %w", err)
+		}
+		res = append(res, kattisTestCase{name: name, input: input, answer: answer})
+	}
+
+	return res, nil
+}
+
+func readKattisTestdataYaml(fsys fs.FS, dirPath string) (KattisTestdataYaml, error) {
+	res := KattisTestdataYaml{}
+	content, err := fs.ReadFile(fsys, path.Join(dirPath, "testdata.yaml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return res, fmt.Errorf("failed to read testdata.yaml: %w", err)
+	}
+	err = yaml.Unmarshal(content, &res)
+	if err != nil {
+		return res, fmt.Errorf("failed to parse %s: %w", path.Join(dirPath, "testdata.yaml"), err)
+	}
+	return res, nil
+}
+
+func addKattisSecretData(ctx context.Context, task *fstaskparser.Task, fsys fs.FS, secretDir string, scoring bool, warnings *[]string) error {
+	secretYaml, err := readKattisTestdataYaml(fsys, secretDir)
+	if err != nil {
+		return err
+	}
+
+	entries, err := fs.ReadDir(fsys, secretDir)
+	if err != nil {
+		return fmt.Errorf("failed to read data/secret: %w", err)
+	}
+
+	groupID := 0
+
+	// test cases directly in data/secret are scored on their own
+	flatCases, err := readKattisTestCases(ctx, fsys, secretDir)
+	if err != nil {
+		return err
+	}
+	if len(flatCases) > 0 {
+		acceptScore := 1
+		if secretYaml.AcceptScore != nil {
+			acceptScore = int(*secretYaml.AcceptScore + 0.5)
+		}
+		if !scoring {
+			*warnings = append(*warnings, "pass-fail problem, all secret test cases are placed in a single group worth 100 points")
+		}
+
+		testIDs := []int{}
+		for _, tc := range flatCases {
+			id := task.AddTest(tc.input, tc.answer)
+			task.AssignFilenameToTest(tc.name, id)
+			if scoring {
+				groupID++
+				err := task.AddTestGroupWithID(groupID, acceptScore, false, []int{id}, groupID)
+				if err != nil {
+					return fmt.Errorf("failed to add test group: %w", err)
+				}
+			}
+			testIDs = append(testIDs, id)
+		}
+		if !scoring {
+			groupID++
+			err := task.AddTestGroupWithID(groupID, 100, false, testIDs, groupID)
+			if err != nil {
+				return fmt.Errorf("failed to add test group: %w", err)
+			}
+		}
+	}
+
+	subtask := groupID
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		subtask++
+		err = addKattisTestGroups(ctx, task, fsys, path.Join(secretDir, entry.Name()), entry.Name(), &groupID, subtask, warnings)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addKattisTestGroups adds the directory as a test group if it holds test
+// cases, then does the same for every directory below it. Groups are
+// named by their path below data/secret.
+func addKattisTestGroups(ctx context.Context, task *fstaskparser.Task, fsys fs.FS, dirPath string, name string, groupID *int, subtask int, warnings *[]string) error {
+	cases, err := readKattisTestCases(ctx, fsys, dirPath)
+	if err != nil {
+		return err
+	}
+	if len(cases) > 0 {
+		*groupID++
+		err = addKattisTestGroup(task, fsys, dirPath, name, cases, *groupID, subtask, warnings)
+		if err != nil {
+			return err
+		}
+	}
+
+	entries, err := fs.ReadDir(fsys, dirPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", dirPath, err)
+	}
+	hasGroups := false
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		hasGroups = true
+		err = addKattisTestGroups(ctx, task, fsys, path.Join(dirPath, entry.Name()), name+"_"+entry.Name(), groupID, subtask, warnings)
+		if err != nil {
+			return err
+		}
+	}
+	if len(cases) == 0 && !hasGroups {
+		*warnings = append(*warnings, fmt.Sprintf("group %s has no test cases", name))
+	}
+
+	return nil
+}
+
+func addKattisTestGroup(task *fstaskparser.Task, fsys fs.FS, groupDir string, name string, cases []kattisTestCase, groupID int, subtask int, warnings *[]string) error {
+	groupYaml, err := readKattisTestdataYaml(fsys, groupDir)
+	if err != nil {
+		return err
+	}
+
+	points, ok := groupYaml.maxScore()
+	if !ok {
+		*warnings = append(*warnings, fmt.Sprintf("group %s has no range or accept_score, giving it 0 points", name))
+	}
+
+	testIDs := []int{}
+	for _, tc := range cases {
+		id := task.AddTest(tc.input, tc.answer)
+		task.AssignFilenameToTest(name+"_"+tc.name, id)
+		testIDs = append(testIDs, id)
+	}
+
+	err = task.AddTestGroupWithID(groupID, points, false, testIDs, subtask)
+	if err != nil {
+		return fmt.Errorf("failed to add test group: %w", err)
+	}
+	return nil
+}
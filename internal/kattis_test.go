@@ -0,0 +1,152 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKattisExportImport(t *testing.T) {
+	task, err := fstaskparser.NewTask("Kvadrātveida putekļsūcējs")
+	require.NoError(t, err)
+	task.SetCPUTimeLimitInSeconds(0.5)
+	task.SetMemoryLimitInMegabytes(128)
+	task.SetTaskAuthors([]string{"Anna", "Jānis"})
+	task.SetProblemTags([]string{"greedy"})
+	task.AddExample([]byte("1 2\n"), []byte("3\n"))
+	a := task.AddTest([]byte("5 5\n"), []byte("10\n"))
+	b := task.AddTest([]byte("1 1\n"), []byte("2\n"))
+	c := task.AddTest([]byte("7 8\n"), []byte("15\n"))
+	require.NoError(t, task.AddTestGroupWithID(1, 40, true, []int{a, b}, 1))
+	require.NoError(t, task.AddTestGroupWithID(2, 25, false, []int{c}, 2))
+	require.NoError(t, task.AddPDFStatement("lv", []byte("%PDF")))
+
+	dir := filepath.Join(t.TempDir(), "kp")
+	require.NoError(t, internal.ExportKattisPackage(task, dir, internal.ExportOptions{}))
+
+	imp, err := internal.DetectImporter(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "kattis", imp.Name())
+
+	imported, _, err := internal.ParseKattisPackageDir(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, task.GetTaskName(), imported.GetTaskName())
+	assert.Equal(t, 0.5, imported.GetCPUTimeLimitInSeconds())
+	assert.Equal(t, 128, imported.GetMemoryLimitInMegabytes())
+	assert.Equal(t, []string{"Anna", "Jānis"}, imported.GetTaskAuthors())
+	assert.Equal(t, []string{"greedy"}, imported.GetProblemTags())
+	assert.Len(t, imported.GetExamples(), 1)
+	assert.Len(t, imported.GetTestsSortedByID(), 3)
+
+	require.Equal(t, []int{1, 2}, imported.GetTestGroupIDs())
+	assert.Equal(t, 40, imported.GetInfoOnTestGroup(1).Points)
+	assert.Equal(t, 1, imported.GetInfoOnTestGroup(1).Subtask)
+	assert.Len(t, imported.GetInfoOnTestGroup(1).TestIDs, 2)
+	assert.Equal(t, 25, imported.GetInfoOnTestGroup(2).Points)
+	assert.Equal(t, 2, imported.GetInfoOnTestGroup(2).Subtask)
+}
+
+func TestKattisCheckerRoundTrip(t *testing.T) {
+	task, err := fstaskparser.NewTask("Kp")
+	require.NoError(t, err)
+	a := task.AddTest([]byte("1\n"), []byte("1\n"))
+	require.NoError(t, task.AddTestGroupWithID(1, 100, false, []int{a}, 1))
+
+	checker := []internal.SourceFile{
+		{Filename: "validator.cpp", Content: []byte("int main() { return 42; }\n")},
+		{Filename: "validate.h", Content: []byte("// validate\n")},
+	}
+	dir := filepath.Join(t.TempDir(), "kp")
+	require.NoError(t, internal.ExportKattisPackage(task, dir, internal.ExportOptions{
+		Checker:     checker,
+		CheckerKind: internal.CheckerKattis,
+	}))
+
+	_, warnings, err := internal.ParseKattisPackageDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	imp, err := internal.GetImporter("kattis")
+	require.NoError(t, err)
+	finder := imp.(internal.CheckerFinder)
+	assert.Equal(t, internal.CheckerKattis, finder.CheckerKind())
+	found, err := finder.FindChecker(os.DirFS(dir))
+	require.NoError(t, err)
+	assert.Equal(t, checker, found)
+}
+
+func TestKattisExportTestlibChecker(t *testing.T) {
+	task, err := fstaskparser.NewTask("Kp")
+	require.NoError(t, err)
+	a := task.AddTest([]byte("1\n"), []byte("1\n"))
+	require.NoError(t, task.AddTestGroupWithID(1, 100, false, []int{a}, 1))
+
+	dir := filepath.Join(t.TempDir(), "kp")
+	require.NoError(t, internal.ExportKattisPackage(task, dir, internal.ExportOptions{
+		Checker: []internal.SourceFile{
+			{Filename: "checker.cpp", Content: []byte("int main() {}\n")},
+			{Filename: "testlib.h", Content: []byte("// testlib\n")},
+		},
+		CheckerKind: internal.CheckerTestlib,
+	}))
+
+	problemYaml, err := os.ReadFile(filepath.Join(dir, "problem.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(problemYaml), "validation: custom")
+
+	validatorDir := filepath.Join(dir, "output_validators", "checker")
+	for _, name := range []string{"checker.cpp", "testlib.h", "build", "run"} {
+		assert.FileExists(t, filepath.Join(validatorDir, name))
+	}
+	run, err := os.Stat(filepath.Join(validatorDir, "run"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), run.Mode().Perm())
+
+	err = internal.ExportKattisPackage(task, filepath.Join(t.TempDir(), "kp"), internal.ExportOptions{
+		Checker:     []internal.SourceFile{{Filename: "checker.py", Content: []byte("print()\n")}},
+		CheckerKind: internal.CheckerTestlib,
+	})
+	assert.ErrorIs(t, err, internal.ErrCheckerNotSupported)
+}
+
+func TestKattisNestedGroups(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"problem.yaml":                                "name: Kp\ntype: scoring\n",
+		"data/sample/1.in":                            "1\n",
+		"data/sample/1.ans":                           "1\n",
+		"data/secret/sub1/testdata.yaml":              "range: 0 30\n",
+		"data/secret/sub1/a/testdata.yaml":            "range: 0 10\n",
+		"data/secret/sub1/a/1.in":                     "2\n",
+		"data/secret/sub1/a/1.ans":                    "2\n",
+		"data/secret/sub1/b/testdata.yaml":            "range: 0 20\n",
+		"data/secret/sub1/b/small/testdata.yaml":      "range: 0 5\n",
+		"data/secret/sub1/b/small/1.in":               "3\n",
+		"data/secret/sub1/b/small/1.ans":              "3\n",
+		"data/secret/sub1/b/large/testdata.yaml":      "range: 0 15\n",
+		"data/secret/sub1/b/large/1.in":               "4\n",
+		"data/secret/sub1/b/large/1.ans":              "4\n",
+		"data/secret/sub1/b/large/huge/testdata.yaml": "range: 0 0\n",
+		"data/secret/sub1/b/large/huge/1.in":          "5\n",
+		"data/secret/sub1/b/large/huge/1.ans":         "5\n",
+	})
+
+	task, warnings, err := internal.ParseKattisPackageDir(dir)
+	require.NoError(t, err)
+	assert.NotContains(t, warnings, "group sub1_b has no test cases")
+
+	require.Equal(t, []int{1, 2, 3, 4}, task.GetTestGroupIDs())
+	points := []int{}
+	for _, id := range task.GetTestGroupIDs() {
+		assert.Equal(t, 1, task.GetInfoOnTestGroup(id).Subtask)
+		points = append(points, task.GetInfoOnTestGroup(id).Points)
+	}
+	assert.Equal(t, []int{10, 15, 0, 5}, points)
+	assert.Equal(t, "sub1_b_large_huge_1", task.GetTestFilenameFromID(task.GetInfoOnTestGroup(3).TestIDs[0]))
+}
@@ -0,0 +1,333 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"gopkg.in/yaml.v2"
+)
+
+type kattisExporter struct{}
+
+func (kattisExporter) Name() string { return "kattis" }
+
+func (kattisExporter) Description() string {
+	return "Kattis/ICPC problem package with testdata.yaml scoring per test group"
+}
+
+func (kattisExporter) Extension() string { return "" }
+
+func (kattisExporter) Export(task *fstaskparser.Task, destPath string, opts ExportOptions) error {
+	return ExportKattisPackage(task, destPath, opts)
+}
+
+type kattisProblemYaml struct {
+	Name       map[string]string `yaml:"name"`
+	Type       string            `yaml:"type"`
+	Validation string            `yaml:"validation,omitempty"`
+	Author     string            `yaml:"author,omitempty"`
+	Source     string            `yaml:"source,omitempty"`
+	Keywords   string            `yaml:"keywords,omitempty"`
+	Limits     struct {
+		TimeLimit float64 `yaml:"time_limit"`
+		Memory    int     `yaml:"memory"`
+	} `yaml:"limits"`
+}
+
+// ExportKattisPackage writes the task as a Kattis problem package. Every
+// subtask becomes a subdirectory of data/secret that sums the scores of
+// its test groups. Every test group becomes a subdirectory of its subtask
+// whose testdata.yaml awards the group's points only if all of its tests
+// pass. A checker is written as the output validator, a testlib one
+// wrapped to follow its interface, and a validator as the input
+// validator.
+func ExportKattisPackage(task *fstaskparser.Task, destPath string, opts ExportOptions) error {
+	if _, err := os.Stat(destPath); !os.IsNotExist(err) {
+		return fmt.Errorf("directory already exists: %s", destPath)
+	}
+	if len(opts.Graders) > 0 {
+		return fmt.Errorf("%w: Kattis packages have no graders (found %s)", ErrGradersNotSupported, opts.Graders[0].Filename)
+	}
+
+	err := writeKattisProblemYaml(task, destPath, "scoring", opts)
+	if err != nil {
+		return err
+	}
+
+	err = writeKattisSamples(task, destPath)
+	if err != nil {
+		return err
+	}
+
+	secretDir := filepath.Join(destPath, "data", "secret")
+	err = os.MkdirAll(secretDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create secret directory: %w", err)
+	}
+
+	tests := map[int][]byte{}
+	answers := map[int][]byte{}
+	for _, t := range task.GetTestsSortedByID() {
+		tests[t.ID] = t.Input
+		answers[t.ID] = t.Answer
+	}
+
+	totalPoints := 0
+	groupIDs := append([]int{}, task.GetTestGroupIDs()...)
+	sort.Ints(groupIDs)
+	grouped := map[int]bool{}
+	subtaskPoints := map[string]int{}
+	for _, groupID := range groupIDs {
+		group := task.GetInfoOnTestGroup(groupID)
+		totalPoints += group.Points
+
+		subtaskDir := filepath.Join(secretDir, fmt.Sprintf("subtask%02d", group.Subtask))
+		subtaskPoints[subtaskDir] += group.Points
+		groupDir := filepath.Join(subtaskDir, fmt.Sprintf("group%03d", groupID))
+
+		points := float64(group.Points)
+		testdata := KattisTestdataYaml{
+			OnReject:    "break",
+			GraderFlags: "min",
+			AcceptScore: &points,
+			Range:       fmt.Sprintf("0 %d", group.Points),
+		}
+		if len(opts.Validator) > 0 {
+			testdata.InputValidatorFlags = strings.Join(ValidatorArgs(groupID, group.Subtask), " ")
+		}
+		err = writeYamlFile(filepath.Join(groupDir, "testdata.yaml"), testdata)
+		if err != nil {
+			return err
+		}
+
+		for _, testID := range group.TestIDs {
+			grouped[testID] = true
+			err = writeTestCase(groupDir, testFilename(task, testID), tests[testID], answers[testID], ".ans")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for subtaskDir, points := range subtaskPoints {
+		err = writeYamlFile(filepath.Join(subtaskDir, "testdata.yaml"), KattisTestdataYaml{
+			OnReject:    "continue",
+			GraderFlags: "sum",
+			Range:       fmt.Sprintf("0 %d", points),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, t := range task.GetTestsSortedByID() {
+		if grouped[t.ID] {
+			continue
+		}
+		// tests outside of groups are worth nothing but are still run
+		ungroupedDir := filepath.Join(secretDir, "ungrouped")
+		err = os.MkdirAll(ungroupedDir, 0755)
+		if err != nil {
+			return fmt.Errorf("failed to create group directory: %w", err)
+		}
+		zero := 0.0
+		err = writeYamlFile(filepath.Join(ungroupedDir, "testdata.yaml"), KattisTestdataYaml{
+			OnReject: "continue", AcceptScore: &zero, Range: "0 0",
+		})
+		if err != nil {
+			return err
+		}
+		err = writeTestCase(ungroupedDir, testFilename(task, t.ID), t.Input, t.Answer, ".ans")
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeYamlFile(filepath.Join(secretDir, "testdata.yaml"), KattisTestdataYaml{
+		OnReject:    "continue",
+		GraderFlags: "sum",
+		Range:       fmt.Sprintf("0 %d", totalPoints),
+	})
+	if err != nil {
+		return err
+	}
+
+	statementDir := filepath.Join(destPath, "problem_statement")
+	err = os.MkdirAll(statementDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create statement directory: %w", err)
+	}
+	for _, st := range task.GetAllPDFStatements() {
+		err = os.WriteFile(filepath.Join(statementDir, "problem."+st.Language+".pdf"), st.Statement, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write PDF statement: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func writeKattisProblemYaml(task *fstaskparser.Task, destPath string, problemType string, opts ExportOptions) error {
+	problemYaml := kattisProblemYaml{
+		// the task name carries no language, LIO tasks are named in Latvian
+		Name:     map[string]string{"lv": task.GetTaskName()},
+		Type:     problemType,
+		Author:   strings.Join(task.GetTaskAuthors(), ", "),
+		Source:   task.GetOriginOlympiad(),
+		Keywords: strings.Join(task.GetProblemTags(), " "),
+	}
+	problemYaml.Limits.TimeLimit = task.GetCPUTimeLimitInSeconds()
+	problemYaml.Limits.Memory = task.GetMemoryLimitInMegabytes()
+
+	if len(opts.Checker) > 0 {
+		problemYaml.Validation = "custom"
+		err := writeKattisOutputValidator(filepath.Join(destPath, "output_validators", "checker"), opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(opts.Validator) > 0 {
+		validatorDir := filepath.Join(destPath, "input_validators", "validator")
+		err := os.MkdirAll(validatorDir, 0755)
+		if err != nil {
+			return fmt.Errorf("failed to create input validator directory: %w", err)
+		}
+		for _, f := range opts.Validator {
+			err = os.WriteFile(filepath.Join(validatorDir, f.Filename), f.Content, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to write input validator: %w", err)
+			}
+		}
+	}
+
+	err := writeYamlFile(filepath.Join(destPath, "problem.yaml"), problemYaml)
+	if err != nil {
+		return err
+	}
+
+	// older problemtools read the time limit from .timelimit only
+	timeLimit := strconv.FormatFloat(task.GetCPUTimeLimitInSeconds(), 'f', -1, 64)
+	err = os.WriteFile(filepath.Join(destPath, ".timelimit"), []byte(timeLimit+"\n"), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write .timelimit: %w", err)
+	}
+
+	return nil
+}
+
+// testlibBuildScript compiles the testlib checker for testlibRunScript,
+// %s is the checker source.
+const testlibBuildScript = `#!/bin/sh
+set -e
+g++ -O2 -std=gnu++17 -o testlib_checker '%s'
+`
+
+// testlibRunScript runs a testlib checker as an output validator. The
+// validator is given the input, the answer and the feedback directory and
+// reads the team output from stdin; testlib checkers read all three from
+// files and exit with 0 on acceptance.
+const testlibRunScript = `#!/bin/sh
+dir=$(dirname "$0")
+cat > "$3/team_output"
+if "$dir/testlib_checker" "$1" "$3/team_output" "$2" 2> "$3/judgemessage.txt"; then
+	exit 42
+fi
+exit 43
+`
+
+// writeKattisOutputValidator writes the checker as the output validator
+// in validatorDir. Testlib checkers are wrapped in build and run scripts
+// that adapt them to the output validator interface.
+func writeKattisOutputValidator(validatorDir string, opts ExportOptions) error {
+	switch opts.CheckerKind {
+	case CheckerKattis:
+	case CheckerTestlib:
+		if !slices.Contains([]string{".cpp", ".cc", ".cxx"}, filepath.Ext(opts.Checker[0].Filename)) {
+			return fmt.Errorf("%w: only C++ testlib checkers can be wrapped as output validators, not %s",
+				ErrCheckerNotSupported, opts.Checker[0].Filename)
+		}
+	default:
+		return fmt.Errorf("%w: output validators are run unlike %q checkers such as %s, port it to the output validator interface",
+			ErrCheckerNotSupported, opts.CheckerKind, opts.Checker[0].Filename)
+	}
+
+	err := os.MkdirAll(validatorDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create output validator directory: %w", err)
+	}
+	for _, f := range opts.Checker {
+		err = os.WriteFile(filepath.Join(validatorDir, f.Filename), f.Content, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write output validator: %w", err)
+		}
+	}
+
+	if opts.CheckerKind != CheckerTestlib {
+		return nil
+	}
+	scripts := map[string]string{
+		"build": fmt.Sprintf(testlibBuildScript, opts.Checker[0].Filename),
+		"run":   testlibRunScript,
+	}
+	for name, content := range scripts {
+		err = os.WriteFile(filepath.Join(validatorDir, name), []byte(content), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to write output validator %s script: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func writeKattisSamples(task *fstaskparser.Task, destPath string) error {
+	sampleDir := filepath.Join(destPath, "data", "sample")
+	err := os.MkdirAll(sampleDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create sample directory: %w", err)
+	}
+	for i, e := range task.GetExamples() {
+		name := fmt.Sprintf("%03d", i+1)
+		if e.Name != nil {
+			name = *e.Name
+		}
+		err = writeTestCase(sampleDir, name, e.Input, e.Output, ".ans")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeYamlFile(path string, v interface{}) error {
+	content, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	err = os.WriteFile(path, content, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func writeTestCase(dirPath string, name string, input []byte, answer []byte, answerExt string) error {
+	err := os.WriteFile(filepath.Join(dirPath, name+".in"), input, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write input file: %w", err)
+	}
+	err = os.WriteFile(filepath.Join(dirPath, name+answerExt), answer, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write answer file: %w", err)
+	}
+	return nil
+}
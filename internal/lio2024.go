@@ -1,57 +1,353 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 
 	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
 )
 
-func ParseLio2024TaskDir(dirPath string) (*fstaskparser.Task, error) {
-	taskYamlPath := filepath.Join(dirPath, "task.yaml")
+func init() {
+	RegisterImporter(lio2024Importer{})
+}
+
+type lio2024Importer struct{}
+
+func (lio2024Importer) Name() string { return "lio2024" }
+
+func (lio2024Importer) Description() string {
+	return "LIO 2024 task directory with task.yaml, testi/tests.zip (or .tar.gz, or a generator plan) and teksts/*.pdf"
+}
 
-	taskYamlContent, err := os.ReadFile(taskYamlPath)
+func (lio2024Importer) Detect(fsys fs.FS) bool {
+	taskYamlContent, err := fs.ReadFile(fsys, "task.yaml")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read task.yaml: %v", err)
+		return false
 	}
-
 	parsedYaml, err := ParseLio2024Yaml(taskYamlContent)
+	return err == nil && (parsedYaml.TestZipPathRelToYaml != "" || parsedYaml.GeneratorPlanRelToYaml != nil)
+}
+
+func (lio2024Importer) Parse(dirPath string) (*fstaskparser.Task, []string, error) {
+	task, _, warnings, err := ParseLio2024TaskWithSources(context.Background(), os.DirFS(dirPath), ParseOptions{}, nil)
+	return task, warnings, err
+}
+
+func (lio2024Importer) ParseFS(ctx context.Context, fsys fs.FS, _ string, opts ParseOptions, timings *StageTimings) (*fstaskparser.Task, []string, error) {
+	task, _, warnings, err := ParseLio2024TaskWithSources(ctx, fsys, opts, timings)
+	return task, warnings, err
+}
+
+func (lio2024Importer) FindChecker(fsys fs.FS) ([]SourceFile, error) {
+	parsedYaml, err := readLio2024Yaml(fsys)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse task.yaml: %v", err)
+		return nil, err
 	}
 
-	if parsedYaml.CheckerPathRelToYaml != nil {
-		// TODO: implement
-		log.Fatalf("found checker %s", *parsedYaml.CheckerPathRelToYaml)
-		return nil, fmt.Errorf("checkers are not implemented yet")
+	if parsedYaml.CheckerPathRelToYaml == nil {
+		return nil, nil
 	}
 
-	if parsedYaml.InteractorPathRelToYaml != nil {
-		// TODO: implement
-		return nil, fmt.Errorf("interactors are not implemented yet")
+	checkerPath, err := lio2024Path(*parsedYaml.CheckerPathRelToYaml)
+	if err != nil {
+		return nil, err
 	}
+	return readSourceFileWithHeaders(fsys, checkerPath)
+}
 
-	task, err := fstaskparser.NewTask(parsedYaml.FullTaskName)
+func (lio2024Importer) CheckerKind() string { return CheckerTestlib }
+
+func (lio2024Importer) FindValidator(fsys fs.FS) ([]SourceFile, error) {
+	parsedYaml, err := readLio2024Yaml(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsedYaml.ValidatorPathRelToYaml == nil {
+		return nil, nil
+	}
+
+	validatorPath, err := lio2024Path(*parsedYaml.ValidatorPathRelToYaml)
+	if err != nil {
+		return nil, err
+	}
+	return readSourceFileWithHeaders(fsys, validatorPath)
+}
+
+// lio2024Path turns a path relative to task.yaml into a path of the task
+// directory fs.FS.
+func lio2024Path(relPath string) (string, error) {
+	res := path.Clean(filepath.ToSlash(relPath))
+	if !fs.ValidPath(res) {
+		return "", fmt.Errorf("path %s is outside of the task directory", relPath)
+	}
+	return res, nil
+}
+
+func (lio2024Importer) UsedFiles(fsys fs.FS) ([]string, error) {
+	parsedYaml, err := readLio2024Yaml(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{"task.yaml", MetadataSidecarFilename, OlympiadConfigFilename}
+	if parsedYaml.TestZipPathRelToYaml != "" {
+		testArchivePath, err := lio2024Path(parsedYaml.TestZipPathRelToYaml)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, testArchivePath)
+	}
+
+	programPaths := []*string{parsedYaml.CheckerPathRelToYaml, parsedYaml.ValidatorPathRelToYaml, parsedYaml.SolutionPathRelToYaml}
+	if parsedYaml.GeneratorPlanRelToYaml != nil {
+		planPath, err := lio2024Path(*parsedYaml.GeneratorPlanRelToYaml)
+		if err != nil {
+			return nil, err
+		}
+		generators, err := generatorPlanFiles(fsys, planPath)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, planPath)
+		for _, g := range generators {
+			programPaths = append(programPaths, &g)
+		}
+	}
+
+	pdfFiles, err := fs.Glob(fsys, "teksts/*.pdf")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find PDF files: %w", err)
+	}
+	res = append(res, pdfFiles...)
+
+	for _, relPath := range programPaths {
+		if relPath == nil {
+			continue
+		}
+		programPath, err := lio2024Path(*relPath)
+		if err != nil {
+			return nil, err
+		}
+		headers, err := fs.Glob(fsys, path.Join(path.Dir(programPath), "*.h"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find headers: %w", err)
+		}
+		res = append(res, programPath)
+		res = append(res, headers...)
+	}
+	return res, nil
+}
+
+// ReadLio2024TaskSources reads the task.yaml and the tests of a LIO 2024
+// task directory as they are, without building a task of them.
+func ReadLio2024TaskSources(dirPath string) (ParsedLio2024Yaml, []LioTest, error) {
+	return ReadLio2024TaskSourcesFS(os.DirFS(dirPath))
+}
+
+// ReadLio2024TaskSourcesFS is ReadLio2024TaskSources for the task directory
+// at the root of fsys.
+func ReadLio2024TaskSourcesFS(fsys fs.FS) (ParsedLio2024Yaml, []LioTest, error) {
+	parsedYaml, err := readLio2024Yaml(fsys)
+	if err != nil {
+		return ParsedLio2024Yaml{}, nil, err
+	}
+	tests, err := readLio2024Tests(context.Background(), fsys, parsedYaml, nil)
+	if err != nil {
+		return ParsedLio2024Yaml{}, nil, err
+	}
+	return parsedYaml, tests, nil
+}
+
+// ImportLio2024Sources reads the task.yaml and the tests of the LIO 2024
+// task directory at the root of fsys the way the lio2024 importer does:
+// generated with opts.GenerateTests and normalised with
+// opts.NormalizeTests. No task is built of them.
+func ImportLio2024Sources(ctx context.Context, fsys fs.FS, opts ParseOptions, timings *StageTimings) (Lio2024Sources, []string, error) {
+	done := timings.Start("yaml parse")
+	parsedYaml, err := readLio2024Yaml(fsys)
+	done()
+	if err != nil {
+		return Lio2024Sources{}, nil, err
+	}
+	tests, warnings, err := importLio2024Tests(ctx, fsys, parsedYaml, opts, timings)
+	if err != nil {
+		return Lio2024Sources{}, nil, err
+	}
+	return Lio2024Sources{Yaml: parsedYaml, Tests: tests}, warnings, nil
+}
+
+// importLio2024Tests generates or reads the tests and normalises them.
+func importLio2024Tests(ctx context.Context, fsys fs.FS, parsedYaml ParsedLio2024Yaml, opts ParseOptions, timings *StageTimings) ([]LioTest, []string, error) {
+	var tests []LioTest
+	var warnings []string
+	var err error
+	if opts.GenerateTests {
+		tests, warnings, err = generateLio2024Tests(ctx, fsys, parsedYaml, timings)
+	} else {
+		tests, err = readLio2024Tests(ctx, fsys, parsedYaml, timings)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create new task: %v", err)
+		return nil, nil, err
 	}
 
-	testZipAbsolutePath := filepath.Join(dirPath, parsedYaml.TestZipPathRelToYaml)
+	normalizeWarnings, err := normalizeLioTests(tests, opts.NormalizeTests, timings)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tests, append(warnings, normalizeWarnings...), nil
+}
+
+func readLio2024Yaml(fsys fs.FS) (ParsedLio2024Yaml, error) {
+	taskYamlContent, err := fs.ReadFile(fsys, "task.yaml")
+	if errors.Is(err, fs.ErrNotExist) {
+		return ParsedLio2024Yaml{}, importErrorf(CodeMissingFile, "task.yaml", 0, "failed to read task.yaml: %w", err)
+	}
+	if err != nil {
+		return ParsedLio2024Yaml{}, fmt.Errorf("failed to read task.yaml: %w", err)
+	}
 
-	tests, err := ReadLioTestsFromZip(testZipAbsolutePath)
+	parsedYaml, err := ParseLio2024Yaml(taskYamlContent)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read tests from zip: %v", err)
+		return ParsedLio2024Yaml{}, importErrorf(CodeInvalidSyntax, "task.yaml", yamlErrorLine(err),
+			"failed to parse task.yaml: %w", err)
 	}
+	return parsedYaml, nil
+}
 
+// readLio2024Tests reads the tests sorted by group and number in group.
+func readLio2024Tests(ctx context.Context, fsys fs.FS, parsedYaml ParsedLio2024Yaml, timings *StageTimings) ([]LioTest, error) {
+	if parsedYaml.TestZipPathRelToYaml == "" {
+		return nil, importErrorf(CodeMissingFile, "task.yaml", 0, "task.yaml names no tests_archive, the tests can only be generated")
+	}
+	testArchivePath, err := lio2024Path(parsedYaml.TestZipPathRelToYaml)
+	if err != nil {
+		return nil, err
+	}
+
+	tests, err := readLioTestsFromArchive(ctx, fsys, testArchivePath, timings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tests from archive: %w", err)
+	}
+
+	sortLioTests(tests)
+	return tests, nil
+}
+
+// sortLioTests sorts the tests by group and number in group.
+func sortLioTests(tests []LioTest) {
 	sort.Slice(tests, func(i, j int) bool {
 		if tests[i].TestGroup == tests[j].TestGroup {
 			return tests[i].NoInTestGroup < tests[j].NoInTestGroup
 		}
 		return tests[i].TestGroup < tests[j].TestGroup
 	})
+}
+
+// generateLio2024Tests runs the generator plan of the task, see
+// GenerateLioTests. If the task ships a test archive as well, the
+// generated tests are compared with it and the differences returned as
+// warnings.
+func generateLio2024Tests(ctx context.Context, fsys fs.FS, parsedYaml ParsedLio2024Yaml, timings *StageTimings) ([]LioTest, []string, error) {
+	if parsedYaml.GeneratorPlanRelToYaml == nil {
+		return nil, nil, importErrorf(CodeMissingFile, "task.yaml", 0, "task.yaml names no generator_plan to generate the tests with")
+	}
+	if parsedYaml.SolutionPathRelToYaml == nil {
+		return nil, nil, importErrorf(CodeMissingFile, "task.yaml", 0, "task.yaml names no solution to answer the generated tests with")
+	}
+	planPath, err := lio2024Path(*parsedYaml.GeneratorPlanRelToYaml)
+	if err != nil {
+		return nil, nil, err
+	}
+	solutionPath, err := lio2024Path(*parsedYaml.SolutionPathRelToYaml)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tests, err := GenerateLioTests(ctx, fsys, planPath, solutionPath, parsedYaml.TaskShortIDCode, timings)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate tests: %w", err)
+	}
+
+	if parsedYaml.TestZipPathRelToYaml == "" {
+		return tests, nil, nil
+	}
+	shipped, err := readLio2024Tests(ctx, fsys, parsedYaml, timings)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tests, CompareLioTests(tests, shipped, path.Base(parsedYaml.TestZipPathRelToYaml)), nil
+}
+
+func ParseLio2024TaskDir(dirPath string) (*fstaskparser.Task, error) {
+	return ParseLio2024TaskFS(os.DirFS(dirPath))
+}
+
+// ParseLio2024TaskFS is ParseLio2024TaskDir for the task directory at the
+// root of fsys. It fails for a task with a checker, which the task cannot
+// hold; the lio2024 importer reads the checker with FindChecker.
+func ParseLio2024TaskFS(fsys fs.FS) (*fstaskparser.Task, error) {
+	task, _, _, err := ParseLio2024TaskWithSources(context.Background(), fsys, ParseOptions{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	checker, err := lio2024Importer{}.FindChecker(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if checker != nil {
+		return nil, fmt.Errorf("%w: the task cannot hold %s, read it with FindChecker", ErrCheckerNotSupported, checker[0].Filename)
+	}
+	return task, nil
+}
+
+// Lio2024Sources are the task.yaml of a LIO 2024 task and the tests the
+// task was built of, after generation and normalisation.
+type Lio2024Sources struct {
+	Yaml  ParsedLio2024Yaml
+	Tests []LioTest
+}
+
+// ParseLio2024TaskWithSources is the ParseFS of the lio2024 importer that
+// also returns what the task was built of, e.g. for Lio2024HtmlReport.
+func ParseLio2024TaskWithSources(ctx context.Context, fsys fs.FS, opts ParseOptions, timings *StageTimings) (*fstaskparser.Task, Lio2024Sources, []string, error) {
+	done := timings.Start("yaml parse")
+	parsedYaml, err := readLio2024Yaml(fsys)
+	done()
+	if err != nil {
+		return nil, Lio2024Sources{}, nil, err
+	}
+	timings.logger().Debug("task.yaml read", "name", parsedYaml.TaskShortIDCode,
+		"groups", len(parsedYaml.TestGroups), "tests_archive", parsedYaml.TestZipPathRelToYaml)
+
+	// the checker is not part of the task, see FindChecker
+
+	if parsedYaml.InteractorPathRelToYaml != nil {
+		// TODO: implement
+		return nil, Lio2024Sources{}, nil, fmt.Errorf("interactors are not implemented yet")
+	}
+
+	task, err := fstaskparser.NewTask(parsedYaml.FullTaskName)
+	if err != nil {
+		return nil, Lio2024Sources{}, nil, fmt.Errorf("failed to create new task: %w", err)
+	}
+
+	tests, warnings, err := importLio2024Tests(ctx, fsys, parsedYaml, opts, timings)
+	if err != nil {
+		return nil, Lio2024Sources{}, nil, err
+	}
+	duplicateWarnings, err := checkDuplicateLioTests(tests)
+	if err != nil {
+		return nil, Lio2024Sources{}, nil, err
+	}
+	warnings = append(warnings, duplicateWarnings...)
 
 	mapTestsToTestGroups := map[int][]int{}
 
@@ -61,7 +357,7 @@ func ParseLio2024TaskDir(dirPath string) (*fstaskparser.Task, error) {
 			continue
 		}
 		id := task.AddTest(t.Input, t.Answer)
-		name := fmt.Sprintf("%03d_%s", t.TestGroup, string(t.NoInTestGroup+int('a')-1))
+		name := fmt.Sprintf("%03d_%s", t.TestGroup, string(rune(t.NoInTestGroup+int('a')-1)))
 		task.AssignFilenameToTest(name, id)
 		mapTestsToTestGroups[t.TestGroup] = append(mapTestsToTestGroups[t.TestGroup], id)
 	}
@@ -74,7 +370,7 @@ func ParseLio2024TaskDir(dirPath string) (*fstaskparser.Task, error) {
 			g.Public, mapTestsToTestGroups[g.GroupID],
 			g.Subtask)
 		if err != nil {
-			return nil, fmt.Errorf("failed to add test group: %v", err)
+			return nil, Lio2024Sources{}, nil, importErrorf(CodeGroupMismatch, "task.yaml", 0, "failed to add test group %d: %w", g.GroupID, err)
 		}
 	}
 
@@ -87,36 +383,56 @@ func ParseLio2024TaskDir(dirPath string) (*fstaskparser.Task, error) {
 	task.SetCPUTimeLimitInSeconds(parsedYaml.CpuTimeLimitInSeconds)
 	task.SetMemoryLimitInMegabytes(parsedYaml.MemoryLimitInMegabytes)
 
-	pdfFilePath := filepath.Join(dirPath, "teksts")
-	pdfFiles, err := filepath.Glob(filepath.Join(pdfFilePath, "*.pdf"))
+	done = timings.Start("statement read")
+	pdfFilePath := "teksts"
+	pdfFiles, err := fs.Glob(fsys, path.Join(pdfFilePath, "*.pdf"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to find PDF files: %w", err)
+		return nil, Lio2024Sources{}, nil, fmt.Errorf("failed to find PDF files: %w", err)
 	}
 
 	if len(pdfFiles) == 0 {
-		return nil, fmt.Errorf("no PDF files found in the directory %s", pdfFilePath)
+		return nil, Lio2024Sources{}, nil, importErrorf(CodeMissingFile, pdfFilePath, 0, "no PDF files found in the directory %s", pdfFilePath)
 	}
 
 	if len(pdfFiles) > 1 {
-		return nil, fmt.Errorf("more than one PDF file found in the directory (%d)", len(pdfFiles))
+		return nil, Lio2024Sources{}, nil, fmt.Errorf("more than one PDF file found in the directory (%d)", len(pdfFiles))
 	}
 
 	pdfStatementPath := pdfFiles[0]
+	timings.logger().Debug("statement found", "path", pdfStatementPath)
 
-	pdfBytes, err := os.ReadFile(pdfStatementPath)
+	pdfBytes, err := fs.ReadFile(fsys, pdfStatementPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read PDF file: %w", err)
+		return nil, Lio2024Sources{}, nil, fmt.Errorf("failed to read PDF file: %w", err)
 	}
 
 	err = task.AddPDFStatement("lv", pdfBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to add PDF statement: %w", err)
+		return nil, Lio2024Sources{}, nil, fmt.Errorf("failed to add PDF statement: %w", err)
 	}
+	done()
 
 	task.AddVisibleInputSubtask(1)
 	task.SetOriginOlympiad("LIO")
 
-	// TODO: implement adding checker and interactor if present
+	sidecarMetadata, err := ReadTaskMetadataSidecarFS(fsys)
+	if err != nil {
+		return nil, Lio2024Sources{}, nil, fmt.Errorf("failed to read task metadata: %w", err)
+	}
 
-	return task, nil
+	metadata := TaskMetadata{
+		Authors:    parsedYaml.Authors,
+		Tags:       parsedYaml.Tags,
+		Difficulty: parsedYaml.Difficulty,
+	}.Merge(sidecarMetadata)
+
+	err = metadata.Validate(nil)
+	if err != nil {
+		return nil, Lio2024Sources{}, nil, fmt.Errorf("invalid task metadata: %w", err)
+	}
+	metadata.Apply(task)
+
+	// TODO: implement adding interactor if present
+
+	return task, Lio2024Sources{Yaml: parsedYaml, Tests: tests}, warnings, nil
 }
@@ -0,0 +1,294 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+)
+
+func init() {
+	RegisterExporter(lio2024Exporter{})
+}
+
+type lio2024Exporter struct{}
+
+func (lio2024Exporter) Name() string { return "lio2024" }
+
+func (lio2024Exporter) Description() string {
+	return "LIO 2024 task directory, readable by the lio2024 source format"
+}
+
+func (lio2024Exporter) Extension() string { return "" }
+
+func (lio2024Exporter) Export(task *fstaskparser.Task, destPath string, opts ExportOptions) error {
+	return ExportLio2024TaskDir(task, destPath, opts)
+}
+
+// ExportLio2024TaskDir writes the task in the layout read by
+// ParseLio2024TaskDir. Consecutive test groups that share points and
+// subtask are written as a single groups range. Examples become group 0.
+func ExportLio2024TaskDir(task *fstaskparser.Task, destPath string, opts ExportOptions) error {
+	if _, err := os.Stat(destPath); !os.IsNotExist(err) {
+		return fmt.Errorf("directory already exists: %s", destPath)
+	}
+
+	if len(opts.Checker) > 0 && opts.CheckerKind != CheckerTestlib {
+		return fmt.Errorf("%w: LIO runs testlib checkers, %s is of kind %q",
+			ErrCheckerNotSupported, opts.Checker[0].Filename, opts.CheckerKind)
+	}
+	if len(opts.Graders) > 0 {
+		return fmt.Errorf("%w: LIO 2024 tasks have no graders (found %s)", ErrGradersNotSupported, opts.Graders[0].Filename)
+	}
+
+	if opts.ShortName == "" || strings.Contains(opts.ShortName, ".") {
+		return fmt.Errorf("invalid short name for LIO test filenames: %q", opts.ShortName)
+	}
+	testPrefix := strings.ToLower(opts.ShortName)
+
+	statement, err := lio2024Statement(task)
+	if err != nil {
+		return err
+	}
+
+	tmpDirPath, err := os.MkdirTemp("", "lio-tests")
+	if err != nil {
+		return fmt.Errorf("failed to create tmp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDirPath)
+
+	examples := task.GetExamples()
+	for i, e := range examples {
+		err = writeLioTest(tmpDirPath, testPrefix, 0, i+1, e.Input, e.Output)
+		if err != nil {
+			return err
+		}
+	}
+
+	tests := map[int][]byte{}
+	answers := map[int][]byte{}
+	for _, t := range task.GetTestsSortedByID() {
+		tests[t.ID] = t.Input
+		answers[t.ID] = t.Answer
+	}
+
+	groupIDs := append([]int{}, task.GetTestGroupIDs()...)
+	sort.Ints(groupIDs)
+	grouped := map[int]bool{}
+	for _, groupID := range groupIDs {
+		if groupID == 0 {
+			return fmt.Errorf("test group 0 is reserved for examples in LIO tasks")
+		}
+		for i, testID := range task.GetInfoOnTestGroup(groupID).TestIDs {
+			grouped[testID] = true
+			err = writeLioTest(tmpDirPath, testPrefix, groupID, i+1, tests[testID], answers[testID])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, t := range task.GetTestsSortedByID() {
+		if !grouped[t.ID] {
+			return fmt.Errorf("test %s is not in any test group", testFilename(task, t.ID))
+		}
+	}
+
+	testiDir := filepath.Join(destPath, "testi")
+	err = os.MkdirAll(testiDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create testi directory: %w", err)
+	}
+
+	zipFile, err := os.Create(filepath.Join(testiDir, "tests.zip"))
+	if err != nil {
+		return fmt.Errorf("failed to create tests.zip: %w", err)
+	}
+	defer zipFile.Close()
+	err = ZipDir(tmpDirPath, zipFile)
+	if err != nil {
+		return fmt.Errorf("failed to write tests.zip: %w", err)
+	}
+	err = zipFile.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write tests.zip: %w", err)
+	}
+
+	rawYaml := lio2024RawYaml{
+		ShortCode:       opts.ShortName,
+		TaskName:        task.GetTaskName(),
+		TimeLimit:       task.GetCPUTimeLimitInSeconds(),
+		MemoryLimit:     task.GetMemoryLimitInMegabytes(),
+		SubtaskPoitns:   lio2024SubtaskPoints(task),
+		TestsZipRelPath: "./testi/tests.zip",
+		Tags:            task.GetProblemTags(),
+		Difficulty:      task.GetDifficultyOneToFive(),
+		TestGroups:      compactLio2024TestGroups(task),
+	}
+	if authors := task.GetTaskAuthors(); len(authors) > 0 {
+		rawYaml.Authors = authors
+	}
+	if len(examples) > 0 {
+		rawYaml.TestGroups = append([]lio2024RawYamlTestGroup{{
+			Groups:  0,
+			Points:  0,
+			Public:  true,
+			Subtask: 0,
+			Comment: "Piemēri",
+		}}, rawYaml.TestGroups...)
+	}
+
+	if len(opts.Checker) > 0 {
+		rikiDir := filepath.Join(destPath, "riki")
+		err = os.MkdirAll(rikiDir, 0755)
+		if err != nil {
+			return fmt.Errorf("failed to create riki directory: %w", err)
+		}
+		for _, f := range opts.Checker {
+			err = os.WriteFile(filepath.Join(rikiDir, f.Filename), f.Content, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to write checker: %w", err)
+			}
+		}
+		checkerPath := "./riki/" + opts.Checker[0].Filename
+		rawYaml.CheckerRelPath = &checkerPath
+	}
+
+	if len(opts.Validator) > 0 {
+		rikiDir := filepath.Join(destPath, "riki")
+		err = os.MkdirAll(rikiDir, 0755)
+		if err != nil {
+			return fmt.Errorf("failed to create riki directory: %w", err)
+		}
+		for _, f := range opts.Validator {
+			err = os.WriteFile(filepath.Join(rikiDir, f.Filename), f.Content, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to write validator: %w", err)
+			}
+		}
+		validatorPath := "./riki/" + opts.Validator[0].Filename
+		rawYaml.ValidatorRelPath = &validatorPath
+	}
+
+	err = writeYamlFile(filepath.Join(destPath, "task.yaml"), rawYaml)
+	if err != nil {
+		return err
+	}
+
+	tekstsDir := filepath.Join(destPath, "teksts")
+	err = os.MkdirAll(tekstsDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create teksts directory: %w", err)
+	}
+	err = os.WriteFile(filepath.Join(tekstsDir, testPrefix+".pdf"), statement, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write PDF statement: %w", err)
+	}
+
+	return nil
+}
+
+// lio2024Statement returns the PDF statement to put in teksts/. LIO tasks
+// have exactly one, so the Latvian one is preferred.
+func lio2024Statement(task *fstaskparser.Task) ([]byte, error) {
+	statements := task.GetAllPDFStatements()
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("LIO tasks require a PDF statement")
+	}
+	sort.Slice(statements, func(i, j int) bool {
+		if (statements[i].Language == "lv") != (statements[j].Language == "lv") {
+			return statements[i].Language == "lv"
+		}
+		return statements[i].Language < statements[j].Language
+	})
+	return statements[0].Statement, nil
+}
+
+// writeLioTest writes the test as kp.i01a and kp.o01a, the naming
+// understood by lioTestName.
+func writeLioTest(dirPath string, prefix string, group int, no int, input []byte, answer []byte) error {
+	if no > 'z'-'a'+1 {
+		return fmt.Errorf("test group %d has more tests than LIO test filenames allow", group)
+	}
+	suffix := fmt.Sprintf("%02d%s", group, string(rune(no+int('a')-1)))
+
+	err := os.WriteFile(filepath.Join(dirPath, prefix+".i"+suffix), input, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write input file: %w", err)
+	}
+	err = os.WriteFile(filepath.Join(dirPath, prefix+".o"+suffix), answer, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write answer file: %w", err)
+	}
+	return nil
+}
+
+func lio2024SubtaskPoints(task *fstaskparser.Task) []int {
+	res := []int{0}
+	for _, groupID := range task.GetTestGroupIDs() {
+		group := task.GetInfoOnTestGroup(groupID)
+		for len(res) <= group.Subtask {
+			res = append(res, 0)
+		}
+		res[group.Subtask] += group.Points
+	}
+	return res
+}
+
+// compactLio2024TestGroups merges runs of consecutive test groups with the
+// same points and subtask into one groups range. The public field of a range
+// can only mark all of its groups or a single one, so a run is also split
+// where that would not be enough.
+func compactLio2024TestGroups(task *fstaskparser.Task) []lio2024RawYamlTestGroup {
+	groupIDs := append([]int{}, task.GetTestGroupIDs()...)
+	sort.Ints(groupIDs)
+
+	res := []lio2024RawYamlTestGroup{}
+	for i := 0; i < len(groupIDs); {
+		first := task.GetInfoOnTestGroup(groupIDs[i])
+		publicIDs := []int{}
+		if first.Public {
+			publicIDs = append(publicIDs, groupIDs[i])
+		}
+
+		j := i + 1
+		for ; j < len(groupIDs); j++ {
+			next := task.GetInfoOnTestGroup(groupIDs[j])
+			if groupIDs[j] != groupIDs[j-1]+1 || next.Points != first.Points || next.Subtask != first.Subtask {
+				break
+			}
+			publicCount := len(publicIDs)
+			if next.Public {
+				publicCount++
+			}
+			if publicCount > 1 && publicCount != j-i+1 {
+				break
+			}
+			if next.Public {
+				publicIDs = append(publicIDs, groupIDs[j])
+			}
+		}
+
+		raw := lio2024RawYamlTestGroup{
+			Points:  first.Points,
+			Subtask: first.Subtask,
+		}
+		if j-i == 1 {
+			raw.Groups = groupIDs[i]
+		} else {
+			raw.Groups = []int{groupIDs[i], groupIDs[j-1]}
+		}
+		switch {
+		case len(publicIDs) == j-i:
+			raw.Public = true
+		case len(publicIDs) == 1:
+			raw.Public = []int{publicIDs[0]}
+		}
+		res = append(res, raw)
+
+		i = j
+	}
+	return res
+}
@@ -0,0 +1,136 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLio2024ExportRoundTrip(t *testing.T) {
+	task := newLio2024ExportTask(t)
+
+	dir := filepath.Join(t.TempDir(), "kp")
+	err := internal.ExportLio2024TaskDir(task, dir, internal.ExportOptions{ShortName: "kp"})
+	require.NoError(t, err)
+
+	taskYaml, err := os.ReadFile(filepath.Join(dir, "task.yaml"))
+	require.NoError(t, err)
+	parsedYaml, err := internal.ParseLio2024Yaml(taskYaml)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 3, 38, 20}, parsedYaml.SubtaskPoints)
+	assert.Contains(t, string(taskYaml), "- groups: [3, 5]\n  points: 10\n  public: [4]\n")
+
+	imported, err := internal.ParseLio2024TaskDir(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, task.GetTaskName(), imported.GetTaskName())
+	assert.Equal(t, task.GetCPUTimeLimitInSeconds(), imported.GetCPUTimeLimitInSeconds())
+	assert.Equal(t, task.GetMemoryLimitInMegabytes(), imported.GetMemoryLimitInMegabytes())
+	assert.Equal(t, task.GetTaskAuthors(), imported.GetTaskAuthors())
+	assert.Equal(t, task.GetProblemTags(), imported.GetProblemTags())
+	assert.Equal(t, task.GetDifficultyOneToFive(), imported.GetDifficultyOneToFive())
+	assert.Equal(t, task.GetExamples(), imported.GetExamples())
+	assert.Equal(t, task.GetAllPDFStatements(), imported.GetAllPDFStatements())
+	assert.Equal(t, testContents(task), testContents(imported))
+
+	require.Equal(t, task.GetTestGroupIDs(), imported.GetTestGroupIDs())
+	for _, groupID := range task.GetTestGroupIDs() {
+		expected := task.GetInfoOnTestGroup(groupID)
+		actual := imported.GetInfoOnTestGroup(groupID)
+		assert.Equal(t, expected.Points, actual.Points, "group %d", groupID)
+		assert.Equal(t, expected.Public, actual.Public, "group %d", groupID)
+		assert.Equal(t, expected.Subtask, actual.Subtask, "group %d", groupID)
+		assert.Equal(t, testInputs(task, expected.TestIDs), testInputs(imported, actual.TestIDs), "group %d", groupID)
+	}
+}
+
+func TestLio2024ExportChecker(t *testing.T) {
+	task := newLio2024ExportTask(t)
+
+	dir := filepath.Join(t.TempDir(), "kp")
+	err := internal.ExportLio2024TaskDir(task, dir, internal.ExportOptions{
+		ShortName:   "kp",
+		Checker:     []internal.SourceFile{{Filename: "checker.cpp", Content: []byte("int main() {}\n")}},
+		CheckerKind: internal.CheckerTestlib,
+	})
+	require.NoError(t, err)
+
+	// the task cannot hold the checker, only the importer reads it
+	_, err = internal.ParseLio2024TaskDir(dir)
+	assert.ErrorIs(t, err, internal.ErrCheckerNotSupported)
+
+	imp, err := internal.GetImporter("lio2024")
+	require.NoError(t, err)
+	imported, _, err := imp.Parse(dir)
+	require.NoError(t, err)
+	assert.Equal(t, task.GetTestGroupIDs(), imported.GetTestGroupIDs())
+
+	checker, err := imp.(internal.CheckerFinder).FindChecker(os.DirFS(dir))
+	require.NoError(t, err)
+	require.Len(t, checker, 1)
+	assert.Equal(t, "int main() {}\n", string(checker[0].Content))
+}
+
+func newLio2024ExportTask(t *testing.T) *fstaskparser.Task {
+	task, err := fstaskparser.NewTask("Kvadrātveida putekļsūcējs")
+	require.NoError(t, err)
+	task.SetCPUTimeLimitInSeconds(0.5)
+	task.SetMemoryLimitInMegabytes(256)
+	task.SetTaskAuthors([]string{"Anna", "Jānis"})
+	task.SetProblemTags([]string{"greedy", "math"})
+	task.SetDifficultyOneToFive(3)
+	task.AddExample([]byte("1 2\n"), []byte("3\n"))
+	task.AddExample([]byte("2 2\n"), []byte("4\n"))
+
+	groups := []struct {
+		points  int
+		public  bool
+		subtask int
+		tests   int
+	}{
+		{3, true, 1, 2},
+		{8, true, 2, 1},
+		{10, false, 2, 2},
+		{10, true, 2, 1},
+		{10, false, 2, 1},
+		{10, false, 3, 1},
+		{10, false, 3, 3},
+	}
+	for i, g := range groups {
+		testIDs := []int{}
+		for j := 0; j < g.tests; j++ {
+			input := []byte{byte('0' + i), byte('a' + j), '\n'}
+			answer := []byte{byte('a' + j), byte('0' + i), '\n'}
+			testIDs = append(testIDs, task.AddTest(input, answer))
+		}
+		require.NoError(t, task.AddTestGroupWithID(i+1, g.points, g.public, testIDs, g.subtask))
+	}
+	require.NoError(t, task.AddPDFStatement("lv", []byte("%PDF")))
+	return task
+}
+
+// testContents lists the input and answer of every test in ID order.
+func testContents(task *fstaskparser.Task) []string {
+	res := []string{}
+	for _, t := range task.GetTestsSortedByID() {
+		res = append(res, string(t.Input)+"|"+string(t.Answer))
+	}
+	return res
+}
+
+func testInputs(task *fstaskparser.Task, testIDs []int) []string {
+	inputs := map[int]string{}
+	for _, t := range task.GetTestsSortedByID() {
+		inputs[t.ID] = string(t.Input)
+	}
+	res := []string{}
+	for _, id := range testIDs {
+		res = append(res, inputs[id])
+	}
+	return res
+}
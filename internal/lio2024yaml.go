@@ -14,8 +14,14 @@ type ParsedLio2024Yaml struct {
 	TestZipPathRelToYaml    string
 	CheckerPathRelToYaml    *string
 	InteractorPathRelToYaml *string
+	ValidatorPathRelToYaml  *string
+	GeneratorPlanRelToYaml  *string
+	SolutionPathRelToYaml   *string
 	SubtaskPoints           []int
 	TestGroups              []ParsedLio2024YamlTestGroup
+	Authors                 []string
+	Tags                    []string
+	Difficulty              int
 }
 
 type ParsedLio2024YamlTestGroup struct {
@@ -27,21 +33,27 @@ type ParsedLio2024YamlTestGroup struct {
 }
 
 type lio2024RawYaml struct {
-	TimeLimit         float64                   `yaml:"time_limit"`
-	MemoryLimit       int                       `yaml:"memory_limit"`
 	ShortCode         string                    `yaml:"name"`
 	TaskName          string                    `yaml:"title"`
-	TestsZipRelPath   string                    `yaml:"tests_archive"`
-	CheckerRelPath    *string                   `yaml:"checker"`
-	InteractorRelPath *string                   `yaml:"interactor"`
-	SubtaskPoitns     []int                     `yaml:"subtask_points"`
+	TimeLimit         float64                   `yaml:"time_limit"`
+	MemoryLimit       int                       `yaml:"memory_limit"`
+	SubtaskPoitns     []int                     `yaml:"subtask_points,flow"`
+	TestsZipRelPath   string                    `yaml:"tests_archive,omitempty"`
+	CheckerRelPath    *string                   `yaml:"checker,omitempty"`
+	InteractorRelPath *string                   `yaml:"interactor,omitempty"`
+	ValidatorRelPath  *string                   `yaml:"validator,omitempty"`
+	GeneratorPlan     *string                   `yaml:"generator_plan,omitempty"`
+	SolutionRelPath   *string                   `yaml:"solution,omitempty"`
+	Authors           interface{}               `yaml:"authors,omitempty"`
+	Tags              []string                  `yaml:"tags,omitempty,flow"`
+	Difficulty        int                       `yaml:"difficulty,omitempty"`
 	TestGroups        []lio2024RawYamlTestGroup `yaml:"tests_groups"`
 }
 
 type lio2024RawYamlTestGroup struct {
-	Groups  interface{} `yaml:"groups"`
+	Groups  interface{} `yaml:"groups,flow"`
 	Points  int         `yaml:"points"`
-	Public  interface{} `yaml:"public"`
+	Public  interface{} `yaml:"public,omitempty,flow"`
 	Subtask int         `yaml:"subtask"`
 	Comment string      `yaml:"comment,omitempty"`
 }
@@ -61,7 +73,18 @@ func ParseLio2024Yaml(content []byte) (res ParsedLio2024Yaml, err error) {
 	res.TestZipPathRelToYaml = rawYaml.TestsZipRelPath
 	res.CheckerPathRelToYaml = rawYaml.CheckerRelPath
 	res.InteractorPathRelToYaml = rawYaml.InteractorRelPath
+	res.ValidatorPathRelToYaml = rawYaml.ValidatorRelPath
+	res.GeneratorPlanRelToYaml = rawYaml.GeneratorPlan
+	res.SolutionPathRelToYaml = rawYaml.SolutionRelPath
 	res.SubtaskPoints = rawYaml.SubtaskPoitns
+	res.Tags = rawYaml.Tags
+	res.Difficulty = rawYaml.Difficulty
+
+	res.Authors, err = stringOrStringList(rawYaml.Authors)
+	if err != nil {
+		err = fmt.Errorf("unsupported authors: %w", err)
+		return
+	}
 
 	for _, group := range rawYaml.TestGroups {
 		groups := []ParsedLio2024YamlTestGroup{}
@@ -157,84 +180,3 @@ func ParseLio2024Yaml(content []byte) (res ParsedLio2024Yaml, err error) {
 
 	return
 }
-
-/*
-excerpt from previous code
-
-for _, group := range task.TestsGroups {
-		groups := []int{}
-
-		switch v := group.Groups.(type) {
-		case int:
-			groups = append(groups, v)
-		case []interface{}:
-			integers := []int{}
-			for _, vv := range v {
-				switch vv := vv.(type) {
-				case int:
-					integers = append(integers, vv)
-				default:
-					log.Fatalf("Unsupported group: %+v %T\n", vv, vv)
-				}
-			}
-			if len(integers) == 1 {
-				groups = append(groups, integers...)
-			} else if len(v) == 2 {
-				for i := integers[0]; i <= integers[1]; i++ {
-					groups = append(groups, i)
-				}
-			} else {
-				log.Fatalf("Unsupported groups length: %v\n", v)
-			}
-		default:
-			log.Fatalf("Unsupported groups: %+v %T\n", v, v)
-		}
-
-		publicGroups := []int{}
-		switch v := group.Public.(type) {
-		case bool:
-			if v {
-				publicGroups = append(publicGroups, groups...)
-			}
-		case []interface{}:
-			integers := []int{}
-			for _, vv := range v {
-				switch vv := vv.(type) {
-				case int:
-					integers = append(integers, vv)
-				default:
-					log.Fatalf("Unsupported public group: %+v %T\n", vv, vv)
-				}
-			}
-
-			if len(integers) == 1 {
-				publicGroups = append(publicGroups, integers...)
-			} else if len(integers) == 2 {
-				for i := integers[0]; i <= integers[1]; i++ {
-					publicGroups = append(publicGroups, i)
-				}
-			} else {
-				log.Fatalf("Unsupported public groups: %v\n", v)
-			}
-		default:
-			log.Fatalf("Unsupported public groups: %v\n", v)
-		}
-
-		for _, g := range groups {
-			// move g 0 files to examples
-			if g == 0 {
-				for _, f := range mapGroupToTestFilenames[g] {
-					err := os.MkdirAll(filepath.Join(newDirPath, "examples"), 0755)
-					if err != nil {
-						log.Fatalf("Failed to create examples directory: %v\n", err)
-					}
-					err = os.Rename(filepath.Join(testsDir, f), filepath.Join(newDirPath, "examples", f))
-					if err != nil {
-						log.Fatalf("Failed to move %s to examples: %v\n", f, err)
-					}
-				}
-				continue
-			}
-		}
-	}
-*/
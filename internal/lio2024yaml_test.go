@@ -49,6 +49,7 @@ tests_groups:
 		CpuTimeLimitInSeconds:  0.5,
 		MemoryLimitInMegabytes: 256,
 		FullTaskName:           "Kvadrātveida putekļsūcējs",
+		TaskShortIDCode:        "Kp",
 		TestZipPathRelToYaml:   "./testi/tests.zip",
 		CheckerPathRelToYaml:   &([]string{"./riki/checker.cpp"}[0]),
 		// InteractorPathRelToYaml: &([]string{"./riki/interactor.cpp"}[0]),
@@ -93,3 +94,24 @@ tests_groups:
 
 	assert.Equal(t, expected, actual)
 }
+
+func TestLio2024YamlMetadata(t *testing.T) {
+	yamlContent := `name: 'kp'
+title: 'Kvadrātveida putekļsūcējs'
+authors: 'Jānis Bērziņš'
+tags: ['greedy', 'geometry']
+difficulty: 3
+`
+
+	actual, err := internal.ParseLio2024Yaml([]byte(yamlContent))
+	require.NoErrorf(t, err, "Failed to parse Lio2024 YAML: %v", err)
+
+	assert.Equal(t, []string{"Jānis Bērziņš"}, actual.Authors)
+	assert.Equal(t, []string{"greedy", "geometry"}, actual.Tags)
+	assert.Equal(t, 3, actual.Difficulty)
+
+	metadata := internal.TaskMetadata{Tags: actual.Tags, Difficulty: actual.Difficulty}
+	assert.NoError(t, metadata.Validate(internal.TagVocabulary{"greedy": true, "geometry": true}))
+	assert.Error(t, metadata.Validate(internal.TagVocabulary{"greedy": true}))
+	assert.Error(t, internal.TaskMetadata{Difficulty: 6}.Validate(nil))
+}
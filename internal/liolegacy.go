@@ -0,0 +1,417 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+)
+
+// LioLegacyPointsFilenames are the files, in order of preference, that
+// may describe the points of a legacy task.
+var LioLegacyPointsFilenames = []string{"punkti.txt", "points.txt", "vertesana.txt"}
+
+var lioTestFnameRegexp = regexp.MustCompile(`^[^.]+\.[io][0-9]+[a-z]?$`)
+
+func init() {
+	RegisterImporter(lioLegacyImporter{name: "lio2023", zippedTests: true})
+	RegisterImporter(lioLegacyImporter{name: "lio-legacy", zippedTests: false})
+}
+
+// lioLegacyImporter is registered twice: lio2023 for tasks that keep
+// their tests in a zip archive and lio-legacy for tasks with loose test
+// files. Both are parsed the same way, the distinction only matters for
+// detection.
+type lioLegacyImporter struct {
+	name        string
+	zippedTests bool
+}
+
+func (imp lioLegacyImporter) Name() string { return imp.name }
+
+func (imp lioLegacyImporter) Description() string {
+	if imp.zippedTests {
+		return "pre-2024 LIO task directory without task.yaml, tests in a testi/*.zip archive"
+	}
+	return "pre-2024 LIO task directory without task.yaml, loose .iNN/.oNN tests in testi/"
+}
+
+func (imp lioLegacyImporter) Detect(fsys fs.FS) bool {
+	if _, err := fs.Stat(fsys, "task.yaml"); err == nil {
+		return false
+	}
+
+	listDir, err := fs.ReadDir(fsys, "testi")
+	if err != nil {
+		return false
+	}
+
+	hasZip, hasTestFiles := false, false
+	for _, entry := range listDir {
+		if strings.HasSuffix(entry.Name(), ".zip") {
+			hasZip = true
+		}
+		if lioTestFnameRegexp.MatchString(entry.Name()) {
+			hasTestFiles = true
+		}
+	}
+
+	if imp.zippedTests {
+		return hasZip
+	}
+	return !hasZip && hasTestFiles
+}
+
+func (imp lioLegacyImporter) Parse(dirPath string) (*fstaskparser.Task, []string, error) {
+	return ParseLioLegacyTaskDir(dirPath)
+}
+
+func (imp lioLegacyImporter) ParseFS(ctx context.Context, fsys fs.FS, name string, opts ParseOptions, timings *StageTimings) (*fstaskparser.Task, []string, error) {
+	return parseLioLegacyTaskFS(ctx, fsys, name, opts, timings)
+}
+
+// LioLegacyPointsLine is a single line of a legacy points file.
+type LioLegacyPointsLine struct {
+	FirstGroup int
+	LastGroup  int
+	Points     int  // points for each group in the range
+	Subtask    *int // nil if the line does not specify it
+}
+
+/*
+Parses the points file of a pre-2024 task. Empty lines and lines
+starting with '#' are skipped. Every other line contains the group
+(or an inclusive range of groups), the points for each of those groups
+and optionally the subtask, separated by whitespace, ':' or ','.
+
+	1 3
+	2-4 8 2
+	5-6: 10
+*/
+func ParseLioLegacyPoints(content []byte) ([]LioLegacyPointsLine, error) {
+	res := []LioLegacyPointsLine{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == ':' || r == ','
+		})
+		if len(fields) != 2 && len(fields) != 3 {
+			return nil, importErrorf(CodeInvalidSyntax, "", lineNo, "expected groups, points and optional subtask: %q", line)
+		}
+
+		parsed := LioLegacyPointsLine{}
+
+		groupRange := strings.SplitN(fields[0], "-", 2)
+		first, err := strconv.Atoi(groupRange[0])
+		if err != nil {
+			return nil, importErrorf(CodeInvalidSyntax, "", lineNo, "failed to convert %s to int: %w", groupRange[0], err)
+		}
+		last := first
+		if len(groupRange) == 2 {
+			last, err = strconv.Atoi(groupRange[1])
+			if err != nil {
+				return nil, importErrorf(CodeInvalidSyntax, "", lineNo, "failed to convert %s to int: %w", groupRange[1], err)
+			}
+		}
+		if last < first {
+			return nil, importErrorf(CodeInvalidSyntax, "", lineNo, "invalid group range %s", fields[0])
+		}
+		parsed.FirstGroup = first
+		parsed.LastGroup = last
+
+		parsed.Points, err = strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, importErrorf(CodeInvalidSyntax, "", lineNo, "failed to convert %s to int: %w", fields[1], err)
+		}
+
+		if len(fields) == 3 {
+			subtask, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, importErrorf(CodeInvalidSyntax, "", lineNo, "failed to convert %s to int: %w", fields[2], err)
+			}
+			parsed.Subtask = &subtask
+		}
+
+		res = append(res, parsed)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ParseLioLegacyTaskDir parses a pre-2024 LIO task directory that has no
+// task.yaml. Tests are read from a zip archive in testi/ or, if there is
+// none, from loose test files in testi/. Whenever missing information is
+// filled in by a heuristic, a warning describing it is returned.
+func ParseLioLegacyTaskDir(dirPath string) (*fstaskparser.Task, []string, error) {
+	return ParseLioLegacyTaskFS(os.DirFS(dirPath), filepath.Base(dirPath))
+}
+
+// ParseLioLegacyTaskFS is ParseLioLegacyTaskDir for the task directory at
+// the root of fsys. The task is named after dirName.
+func ParseLioLegacyTaskFS(fsys fs.FS, dirName string) (*fstaskparser.Task, []string, error) {
+	return parseLioLegacyTaskFS(context.Background(), fsys, dirName, ParseOptions{}, nil)
+}
+
+func parseLioLegacyTaskFS(ctx context.Context, fsys fs.FS, dirName string, opts ParseOptions, timings *StageTimings) (*fstaskparser.Task, []string, error) {
+	if opts.GenerateTests {
+		return nil, nil, fmt.Errorf("legacy LIO tasks have no generator plan to generate the tests with")
+	}
+
+	warnings := []string{}
+
+	taskName := dirName
+	warnings = append(warnings, fmt.Sprintf("task has no title, using directory name %q", taskName))
+
+	task, err := fstaskparser.NewTask(taskName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create new task: %w", err)
+	}
+
+	tests, testWarnings, err := readLioLegacyTests(ctx, fsys, "testi", timings)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, testWarnings...)
+
+	normalizeWarnings, err := normalizeLioTests(tests, opts.NormalizeTests, timings)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, normalizeWarnings...)
+	duplicateWarnings, err := checkDuplicateLioTests(tests)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, duplicateWarnings...)
+
+	sort.Slice(tests, func(i, j int) bool {
+		if tests[i].TestGroup == tests[j].TestGroup {
+			return tests[i].NoInTestGroup < tests[j].NoInTestGroup
+		}
+		return tests[i].TestGroup < tests[j].TestGroup
+	})
+
+	mapTestsToTestGroups := map[int][]int{}
+	groupIDs := []int{}
+
+	for _, t := range tests {
+		if t.TestGroup == 0 {
+			task.AddExample(t.Input, t.Answer)
+			continue
+		}
+		id := task.AddTest(t.Input, t.Answer)
+		name := fmt.Sprintf("%03d_%s", t.TestGroup, string(rune(t.NoInTestGroup+int('a')-1)))
+		task.AssignFilenameToTest(name, id)
+		if _, ok := mapTestsToTestGroups[t.TestGroup]; !ok {
+			groupIDs = append(groupIDs, t.TestGroup)
+		}
+		mapTestsToTestGroups[t.TestGroup] = append(mapTestsToTestGroups[t.TestGroup], id)
+	}
+
+	groupPoints, groupSubtasks, pointsWarnings, err := readLioLegacyGroupPoints(fsys, groupIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, pointsWarnings...)
+
+	for _, g := range groupIDs {
+		err := task.AddTestGroupWithID(g, groupPoints[g], false,
+			mapTestsToTestGroups[g], groupSubtasks[g])
+		if err != nil {
+			return nil, nil, importErrorf(CodeGroupMismatch, "", 0, "failed to add test group %d: %w", g, err)
+		}
+	}
+
+	warnings = append(warnings, fmt.Sprintf("task has no limits, using defaults of %.1f s and %d MB",
+		task.GetCPUTimeLimitInSeconds(), task.GetMemoryLimitInMegabytes()))
+
+	pdfFiles, err := fs.Glob(fsys, "teksts/*.pdf")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find PDF files: %w", err)
+	}
+	if len(pdfFiles) == 0 {
+		pdfFiles, err = fs.Glob(fsys, "*.pdf")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find PDF files: %w", err)
+		}
+	}
+
+	switch {
+	case len(pdfFiles) == 0:
+		warnings = append(warnings, "no PDF statement found, importing the task without a statement")
+	default:
+		if len(pdfFiles) > 1 {
+			warnings = append(warnings, fmt.Sprintf("%d PDF files found, using %s as the statement",
+				len(pdfFiles), path.Base(pdfFiles[0])))
+		}
+		pdfBytes, err := fs.ReadFile(fsys, pdfFiles[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read PDF file: %w", err)
+		}
+		err = task.AddPDFStatement("lv", pdfBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to add PDF statement: %w", err)
+		}
+	}
+
+	task.AddVisibleInputSubtask(1)
+	task.SetOriginOlympiad("LIO")
+
+	metadata, err := ReadTaskMetadataSidecarFS(fsys)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read task metadata: %w", err)
+	}
+	err = metadata.Validate(nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid task metadata: %w", err)
+	}
+	metadata.Apply(task)
+
+	return task, warnings, nil
+}
+
+func readLioLegacyTests(ctx context.Context, fsys fs.FS, testDir string, timings *StageTimings) ([]LioTest, []string, error) {
+	warnings := []string{}
+
+	zipFiles, err := fs.Glob(fsys, path.Join(testDir, "*.zip"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find test archives: %w", err)
+	}
+	if len(zipFiles) > 0 {
+		if len(zipFiles) > 1 {
+			warnings = append(warnings, fmt.Sprintf("%d test archives found, using %s",
+				len(zipFiles), path.Base(zipFiles[0])))
+		}
+		tests, err := readLioTestsFromArchive(ctx, fsys, zipFiles[0], timings)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read tests from zip: %w", err)
+		}
+		return tests, warnings, nil
+	}
+
+	listDir, err := fs.ReadDir(fsys, testDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read directory %s: %w", testDir, err)
+	}
+
+	fnames := []string{}
+	for _, entry := range listDir {
+		if entry.IsDir() || !lioTestFnameRegexp.MatchString(entry.Name()) {
+			warnings = append(warnings, fmt.Sprintf("ignoring %s in the test directory", entry.Name()))
+			continue
+		}
+		fnames = append(fnames, entry.Name())
+	}
+
+	done := timings.Start("test read")
+	tests, err := readLioTestFiles(ctx, fsys, testDir, fnames, timings)
+	done()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read tests: %w", err)
+	}
+
+	return tests, warnings, nil
+}
+
+func readLioLegacyGroupPoints(fsys fs.FS, groupIDs []int) (map[int]int, map[int]int, []string, error) {
+	points := map[int]int{}
+	subtasks := map[int]int{}
+	warnings := []string{}
+
+	var content []byte
+	var pointsFname string
+	for _, fname := range LioLegacyPointsFilenames {
+		c, err := fs.ReadFile(fsys, fname)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to read %s: %w", fname, err)
+		}
+		content = c
+		pointsFname = fname
+		break
+	}
+
+	if pointsFname == "" {
+		// distribute 100 points evenly, giving the remainder to the last groups
+		warnings = append(warnings, "no points file found, distributing 100 points evenly among the test groups")
+		for i, g := range groupIDs {
+			points[g] = 100 / len(groupIDs)
+			if i >= len(groupIDs)-100%len(groupIDs) {
+				points[g]++
+			}
+			subtasks[g] = i + 1
+		}
+		if len(groupIDs) > 0 {
+			warnings = append(warnings, "no subtasks specified, placing each test group in a subtask of its own")
+		}
+		return points, subtasks, warnings, nil
+	}
+
+	lines, err := ParseLioLegacyPoints(content)
+	importErr := &ImportError{}
+	if errors.As(err, &importErr) {
+		importErr.File = pointsFname
+		return nil, nil, nil, importErr
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse %s: %w", pointsFname, err)
+	}
+
+	subtaskGuessed := false
+	for i, line := range lines {
+		subtask := i + 1
+		if line.Subtask != nil {
+			subtask = *line.Subtask
+		} else {
+			subtaskGuessed = true
+		}
+		for g := line.FirstGroup; g <= line.LastGroup; g++ {
+			points[g] = line.Points
+			subtasks[g] = subtask
+		}
+	}
+	if subtaskGuessed {
+		warnings = append(warnings, fmt.Sprintf("%s does not specify subtasks, placing each line in a subtask of its own", pointsFname))
+	}
+
+	hasTests := map[int]bool{}
+	for _, g := range groupIDs {
+		hasTests[g] = true
+		if _, ok := points[g]; !ok {
+			warnings = append(warnings, fmt.Sprintf("%s does not mention group %d, giving it 0 points", pointsFname, g))
+		}
+	}
+	for _, line := range lines {
+		for g := line.FirstGroup; g <= line.LastGroup; g++ {
+			if !hasTests[g] && g != 0 {
+				warnings = append(warnings, fmt.Sprintf("%s mentions group %d which has no tests", pointsFname, g))
+			}
+		}
+	}
+
+	return points, subtasks, warnings, nil
+}
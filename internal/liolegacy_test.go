@@ -0,0 +1,68 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLioLegacyPoints(t *testing.T) {
+	content := `# grupa punkti apakšuzdevums
+1 3
+2-4 8 2
+5-6: 10
+`
+	two := 2
+	expected := []internal.LioLegacyPointsLine{
+		{FirstGroup: 1, LastGroup: 1, Points: 3},
+		{FirstGroup: 2, LastGroup: 4, Points: 8, Subtask: &two},
+		{FirstGroup: 5, LastGroup: 6, Points: 10},
+	}
+
+	actual, err := internal.ParseLioLegacyPoints([]byte(content))
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	_, err = internal.ParseLioLegacyPoints([]byte("3-1 5\n"))
+	assert.Error(t, err)
+}
+
+func TestParseLioLegacyTaskDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "kp")
+	testDir := filepath.Join(dir, "testi")
+	require.NoError(t, os.MkdirAll(testDir, 0755))
+
+	files := map[string]string{
+		"kp.i00":   "1 2\n",
+		"kp.o00":   "3\n",
+		"kp.i01a":  "5 5\n",
+		"kp.o01a":  "10\n",
+		"kp.i01b":  "1 1\n",
+		"kp.o01b":  "2\n",
+		"kp.i02":   "7 8\n",
+		"kp.o02":   "15\n",
+		"piezimes": "ignored",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(testDir, name), []byte(content), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "punkti.txt"), []byte("1 40 1\n2 60 2\n"), 0644))
+
+	task, warnings, err := internal.ParseLioLegacyTaskDir(dir)
+	require.NoError(t, err)
+
+	assert.Contains(t, warnings, "ignoring piezimes in the test directory")
+	assert.Equal(t, "kp", task.GetTaskName())
+	assert.Len(t, task.GetExamples(), 1)
+	assert.Len(t, task.GetTestsSortedByID(), 3)
+	assert.Equal(t, []int{1, 2}, task.GetTestGroupIDs())
+
+	group := task.GetInfoOnTestGroup(2)
+	assert.Equal(t, 60, group.Points)
+	assert.Equal(t, 2, group.Subtask)
+	assert.Len(t, group.TestIDs, 1)
+}
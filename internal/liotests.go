@@ -1,8 +1,12 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -21,85 +25,140 @@ type LioTest struct {
 }
 
 func ReadLioTestsFromZip(testZipPath string) ([]LioTest, error) {
-	// create a tmp directory where to unzip the test zip
-	tmpDirPath, err := os.MkdirTemp("", "lio-tests")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create tmp directory: %v", err)
+	return ReadLioTestsFromArchive(testZipPath)
+}
+
+// ReadLioTestsFromArchive reads the tests from a .zip, .tar.gz or .tgz
+// archive. Archives with any other extension are read as zip, as tests
+// archives were before tar.gz was supported.
+func ReadLioTestsFromArchive(testArchivePath string) ([]LioTest, error) {
+	return ReadLioTestsFromArchiveFS(os.DirFS(filepath.Dir(testArchivePath)), filepath.Base(testArchivePath))
+}
+
+// ReadLioTestsFromArchiveFS reads the tests from the named archive in fsys.
+// The archive is read into memory, nothing is extracted to disk.
+func ReadLioTestsFromArchiveFS(fsys fs.FS, name string) ([]LioTest, error) {
+	return readLioTestsFromArchive(context.Background(), fsys, name, nil)
+}
+
+func readLioTestsFromArchive(ctx context.Context, fsys fs.FS, name string, timings *StageTimings) ([]LioTest, error) {
+	format := ArchiveFormatFromPath(name)
+	if format == "" {
+		format = ArchiveZip
 	}
-	defer os.RemoveAll(tmpDirPath)
 
-	err = Unzip(testZipPath, tmpDirPath)
+	done := timings.Start("unzip")
+	content, err := fs.ReadFile(fsys, name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unzip %s: %v", testZipPath, err)
+		done()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, importErrorf(CodeMissingFile, name, 0, "test archive not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	archive, err := ArchiveFS(ctx, content, format)
+	done()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
 	}
 
-	return ReadLioTestsFromDir(tmpDirPath)
+	done = timings.Start("test read")
+	defer done()
+	return readLioTestsFromFS(ctx, archive, timings)
 }
 
 func ReadLioTestsFromDir(testDir string) ([]LioTest, error) {
-	res := []LioTest{}
+	return ReadLioTestsFromFS(os.DirFS(testDir))
+}
+
+// ReadLioTestsFromFS reads the tests from the root of fsys, which must only
+// hold test inputs and answers.
+func ReadLioTestsFromFS(fsys fs.FS) ([]LioTest, error) {
+	return readLioTestsFromFS(context.Background(), fsys, nil)
+}
 
-	listDir, err := os.ReadDir(testDir)
+func readLioTestsFromFS(ctx context.Context, fsys fs.FS, timings *StageTimings) ([]LioTest, error) {
+	listDir, err := fs.ReadDir(fsys, ".")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read directory %s: %v", testDir, err)
+		return nil, fmt.Errorf("failed to read test directory: %w", err)
+	}
+
+	fnames := []string{}
+	for _, entry := range listDir {
+		fnames = append(fnames, entry.Name())
 	}
 
+	return readLioTestFiles(ctx, fsys, ".", fnames, timings)
+}
+
+// readLioTestFiles reads the named test files from the directory of fsys.
+// The files must all be inputs and answers of LIO tests. The context is
+// checked before every test.
+func readLioTestFiles(ctx context.Context, fsys fs.FS, testDir string, fnames []string, timings *StageTimings) ([]LioTest, error) {
+	res := []LioTest{}
+	var bytesRead int64
+
 	// sort by filename in lexicographical order
-	sort.Slice(listDir, func(i, j int) bool {
-		return listDir[i].Name() < listDir[j].Name()
-	})
+	sort.Strings(fnames)
 
-	if len(listDir)%2 != 0 {
-		return nil, fmt.Errorf("unexpected number of files in the directory: %d", len(listDir))
+	if len(fnames)%2 != 0 {
+		return nil, importErrorf(CodeBadTestFilename, "", 0,
+			"unexpected number of files in the directory: %d, every input needs an answer", len(fnames))
 	}
 
-	inputEntries := listDir[:len(listDir)/2]
-	answerEntries := listDir[len(listDir)/2:]
+	inputFnames := fnames[:len(fnames)/2]
+	answerFnames := fnames[len(fnames)/2:]
 
-	for i := 0; i < len(inputEntries); i++ {
-		inputPath := filepath.Join(testDir, inputEntries[i].Name())
-		answerPath := filepath.Join(testDir, answerEntries[i].Name())
+	for i := 0; i < len(inputFnames); i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		inputPath := path.Join(testDir, inputFnames[i])
+		answerPath := path.Join(testDir, answerFnames[i])
 
-		inFname := filepath.Base(inputPath)
-		ansFname := filepath.Base(answerPath)
+		inFname := path.Base(inputPath)
+		ansFname := path.Base(answerPath)
 
 		inFnameSplit, err := lioTestName(inFname)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse input filename: %v", err)
+			return nil, importErrorf(CodeBadTestFilename, inFname, 0, "failed to parse input filename: %w", err)
 		}
 		ansFnameSplit, err := lioTestName(ansFname)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse answer filename: %v", err)
+			return nil, importErrorf(CodeBadTestFilename, ansFname, 0, "failed to parse answer filename: %w", err)
 		}
 
 		inTaskName := inFnameSplit[0]
 		ansTaskName := ansFnameSplit[0]
 
 		if inTaskName != ansTaskName {
-			return nil, fmt.Errorf("input and answer task names do not match: %s, %s", inTaskName, ansTaskName)
+			return nil, importErrorf(CodeBadTestFilename, inFname, 0,
+				"input and answer task names do not match: %s, %s", inTaskName, ansTaskName)
 		}
 
 		if inFnameSplit[1] != "i" || ansFnameSplit[1] != "o" {
-			return nil, fmt.Errorf("unexpected filename format: %s, %s", inFname, ansFname)
+			return nil, importErrorf(CodeBadTestFilename, inFname, 0, "unexpected filename format: %s, %s", inFname, ansFname)
 		}
 
 		inGroup, err := strconv.Atoi(inFnameSplit[2])
 		if err != nil {
-			return nil, fmt.Errorf("failed to convert %s to int: %v", inFnameSplit[2], err)
+			return nil, importErrorf(CodeBadTestFilename, inFname, 0, "failed to convert %s to int: %w", inFnameSplit[2], err)
 		}
 		ansGroup, err := strconv.Atoi(ansFnameSplit[2])
 		if err != nil {
-			return nil, fmt.Errorf("failed to convert %s to int: %v", ansFnameSplit[2], err)
+			return nil, importErrorf(CodeBadTestFilename, ansFname, 0, "failed to convert %s to int: %w", ansFnameSplit[2], err)
 		}
 
 		if inGroup != ansGroup {
-			return nil, fmt.Errorf("input and answer groups do not match: %d, %d", inGroup, ansGroup)
+			return nil, importErrorf(CodeBadTestFilename, inFname, 0,
+				"input and answer groups do not match: %d, %d", inGroup, ansGroup)
 		}
 
 		inGroupNo := 1
 		if len(inFnameSplit) == 4 {
 			if len(inFnameSplit[3]) != 1 {
-				return nil, fmt.Errorf("unexpected filename format: %s", inFname)
+				return nil, importErrorf(CodeBadTestFilename, inFname, 0, "unexpected filename format: %s", inFname)
 			}
 			inGroupNo = int(inFnameSplit[3][0]) - int('a') + 1
 		}
@@ -107,22 +166,23 @@ func ReadLioTestsFromDir(testDir string) ([]LioTest, error) {
 		ansGroupNo := 1
 		if len(ansFnameSplit) == 4 {
 			if len(ansFnameSplit[3]) != 1 {
-				return nil, fmt.Errorf("unexpected filename format: %s", ansFname)
+				return nil, importErrorf(CodeBadTestFilename, ansFname, 0, "unexpected filename format: %s", ansFname)
 			}
 			ansGroupNo = int(ansFnameSplit[3][0]) - int('a') + 1
 		}
 
 		if inGroupNo != ansGroupNo {
-			return nil, fmt.Errorf("input and answer groups do not match: %d, %d", inGroupNo, ansGroupNo)
+			return nil, importErrorf(CodeBadTestFilename, inFname, 0,
+				"input and answer groups do not match: %d, %d", inGroupNo, ansGroupNo)
 		}
 
-		inBytes, err := os.ReadFile(inputPath)
+		inBytes, err := fs.ReadFile(fsys, inputPath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read input file: %v", err)
+			return nil, fmt.Errorf("failed to read input file: %w", err)
 		}
-		ansBytes, err := os.ReadFile(answerPath)
+		ansBytes, err := fs.ReadFile(fsys, answerPath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read answer file: %v", err)
+			return nil, fmt.Errorf("failed to read answer file: %w", err)
 		}
 
 		res = append(res, LioTest{
@@ -133,7 +193,10 @@ func ReadLioTestsFromDir(testDir string) ([]LioTest, error) {
 			Input:             inBytes,
 			Answer:            ansBytes,
 		})
+		bytesRead += int64(len(inBytes) + len(ansBytes))
+		timings.TestsRead(i+1, len(inputFnames), bytesRead)
 	}
+	timings.logger().Debug("tests read", "dir", testDir, "tests", len(res), "bytes", bytesRead)
 
 	return res, nil
 }
@@ -156,6 +219,9 @@ func lioTestName(fname string) ([]string, error) {
 	res = append(res, splitByDot[0])
 
 	ext := splitByDot[1]
+	if len(ext) == 0 {
+		return nil, fmt.Errorf("unexpected filename: %s", fname)
+	}
 	if ext[0] != 'i' && ext[0] != 'o' {
 		return nil, fmt.Errorf("unexpected second part: %s", ext)
 	}
@@ -181,70 +247,3 @@ func lioTestName(fname string) ([]string, error) {
 
 	return res, nil
 }
-
-/*
-
-	zipPath := filepath.Join(*sourceDir, "testi", "tests.zip")
-	err = internal.Unzip(zipPath, filepath.Join(newDirPath, "tests"))
-	if err != nil {
-		log.Fatalf("Failed to unzip %s: %v\n", zipPath, err)
-	}
-*/
-
-/*
-
-	mapGroupToTestFilenames := map[int][]string{}
-	testsDir := filepath.Join(newDirPath, "tests")
-	files, err := os.ReadDir(testsDir)
-	if err != nil {
-		log.Fatalf("Failed to read directory %s: %v\n", testsDir, err)
-	}
-	for _, file := range files {
-		if file.IsDir() {
-			log.Fatalf("Unexpected directory in the tests directory: %s\n", file.Name())
-		}
-		fname := file.Name()
-		// split into part by dot. keep the last part (the extension)
-		parts := strings.Split(fname, ".")
-		if len(parts) < 2 {
-			log.Fatalf("Unexpected filename: %s\n", fname)
-		}
-
-		ext := parts[len(parts)-1]
-
-		re := regexp.MustCompile("[0-9]+")
-		dGroups := re.FindAllString(ext, -1)
-		if len(dGroups) != 1 {
-			log.Fatalf("Unexpected filename: %s\n", fname)
-		}
-
-		group, err := strconv.Atoi(dGroups[0])
-		if err != nil {
-			log.Fatalf("Failed to convert %s to int: %v\n", dGroups[0], err)
-		}
-
-		// rename the file. if it has ".i" in the name, append "in" to the end
-		// if it has ".o" in the name, append "out" to the end
-		// remove ".i" and ".o" from the name
-
-		if strings.Contains(fname, ".i") {
-			newFname := strings.ReplaceAll(fname, ".i", "")
-			newFname += ".in"
-			err := os.Rename(filepath.Join(testsDir, fname), filepath.Join(testsDir, newFname))
-			if err != nil {
-				log.Fatalf("Failed to rename %s to %s: %v\n", fname, newFname, err)
-			}
-			fname = newFname
-		} else if strings.Contains(fname, ".o") {
-			newFname := strings.ReplaceAll(fname, ".o", "")
-			newFname += ".out"
-			err := os.Rename(filepath.Join(testsDir, fname), filepath.Join(testsDir, newFname))
-			if err != nil {
-				log.Fatalf("Failed to rename %s to %s: %v\n", fname, newFname, err)
-			}
-			fname = newFname
-		}
-
-		mapGroupToTestFilenames[group] = append(mapGroupToTestFilenames[group], fname)
-	}
-*/
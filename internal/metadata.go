@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"gopkg.in/yaml.v2"
+)
+
+// MetadataSidecarFilename is the name of the file next to task.yaml
+// (or in a legacy task directory) holding metadata for tasks whose
+// task.yaml predates the authors, tags and difficulty keys.
+const MetadataSidecarFilename = "metadata.yaml"
+
+// TaskMetadata is the descriptive information about a task that
+// is not needed to evaluate submissions.
+type TaskMetadata struct {
+	Authors    []string
+	Tags       []string
+	Difficulty int
+}
+
+type rawTaskMetadata struct {
+	Authors    interface{} `yaml:"authors"`
+	Tags       []string    `yaml:"tags"`
+	Difficulty int         `yaml:"difficulty"`
+}
+
+// ReadTaskMetadataSidecar reads the metadata sidecar file from the task
+// directory. A missing file results in empty metadata.
+func ReadTaskMetadataSidecar(dirPath string) (TaskMetadata, error) {
+	return ReadTaskMetadataSidecarFS(os.DirFS(dirPath))
+}
+
+// ReadTaskMetadataSidecarFS is ReadTaskMetadataSidecar for the task
+// directory at the root of fsys.
+func ReadTaskMetadataSidecarFS(fsys fs.FS) (TaskMetadata, error) {
+	content, err := fs.ReadFile(fsys, MetadataSidecarFilename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return TaskMetadata{}, nil
+	}
+	if err != nil {
+		return TaskMetadata{}, fmt.Errorf("failed to read %s: %w", MetadataSidecarFilename, err)
+	}
+
+	raw := rawTaskMetadata{}
+	err = yaml.UnmarshalStrict(content, &raw)
+	if err != nil {
+		return TaskMetadata{}, importErrorf(CodeInvalidSyntax, MetadataSidecarFilename, yamlErrorLine(err),
+			"failed to parse %s: %w", MetadataSidecarFilename, err)
+	}
+
+	authors, err := stringOrStringList(raw.Authors)
+	if err != nil {
+		return TaskMetadata{}, importErrorf(CodeInvalidSyntax, MetadataSidecarFilename, 0,
+			"unsupported authors in %s: %w", MetadataSidecarFilename, err)
+	}
+
+	return TaskMetadata{
+		Authors:    authors,
+		Tags:       raw.Tags,
+		Difficulty: raw.Difficulty,
+	}, nil
+}
+
+// Merge fills the empty fields of m with the values from other.
+func (m TaskMetadata) Merge(other TaskMetadata) TaskMetadata {
+	if len(m.Authors) == 0 {
+		m.Authors = other.Authors
+	}
+	if len(m.Tags) == 0 {
+		m.Tags = other.Tags
+	}
+	if m.Difficulty == 0 {
+		m.Difficulty = other.Difficulty
+	}
+	return m
+}
+
+// Validate checks that the difficulty is either unset or between 1 and 5
+// and, if a vocabulary is given, that every tag belongs to it. All
+// problems are reported at once in an ErrorList.
+func (m TaskMetadata) Validate(vocabulary TagVocabulary) error {
+	res := ErrorList{}
+
+	if m.Difficulty < 0 || m.Difficulty > 5 {
+		res = append(res, importErrorf(CodeLimitExceeded, "", 0,
+			"difficulty must be between 1 and 5, got %d", m.Difficulty))
+	}
+
+	unknown := []string{}
+	for _, tag := range m.Tags {
+		if vocabulary != nil && !vocabulary[tag] {
+			unknown = append(unknown, tag)
+		}
+	}
+	if len(unknown) > 0 {
+		res = append(res, importErrorf(CodeUnknownTag, "", 0,
+			"tags not in vocabulary: %s", strings.Join(unknown, ", ")))
+	}
+
+	return res.Err()
+}
+
+// Apply stores the metadata on the task.
+func (m TaskMetadata) Apply(task *fstaskparser.Task) {
+	authors := m.Authors
+	if authors == nil {
+		authors = []string{}
+	}
+	tags := m.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	task.SetTaskAuthors(authors)
+	task.SetProblemTags(tags)
+	task.SetDifficultyOneToFive(m.Difficulty)
+}
+
+// TagVocabulary is the set of tags that tasks are allowed to use.
+type TagVocabulary map[string]bool
+
+// ReadTagVocabulary reads a tag vocabulary file. The file lists one tag
+// per line; empty lines and lines starting with '#' are skipped.
+func ReadTagVocabulary(filePath string) (TagVocabulary, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tag vocabulary: %w", err)
+	}
+
+	res := TagVocabulary{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		res[line] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tag vocabulary: %w", err)
+	}
+
+	return res, nil
+}
+
+/*
+"Jānis" -> ["Jānis"]
+["Jānis", "Anna"] -> ["Jānis", "Anna"]
+*/
+func stringOrStringList(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		res := []string{}
+		for _, vv := range v {
+			s, ok := vv.(string)
+			if !ok {
+				return nil, fmt.Errorf("%+v %T", vv, vv)
+			}
+			res = append(res, s)
+		}
+		return res, nil
+	default:
+		return nil, fmt.Errorf("%+v %T", v, v)
+	}
+}
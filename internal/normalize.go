@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Test normalisation modes, see NormalizeLioTests.
+const (
+	// NormalizeOff takes tests as they are.
+	NormalizeOff = "off"
+	// NormalizeFix fixes the tests and reports what it changed.
+	NormalizeFix = "fix"
+	// NormalizeStrict reports what NormalizeFix would change, leaving the
+	// tests as they are.
+	NormalizeStrict = "strict"
+)
+
+// NormalizeModes lists the test normalisation modes.
+var NormalizeModes = []string{NormalizeOff, NormalizeFix, NormalizeStrict}
+
+// LioFormats are the source formats whose tests are normalised, the other
+// importers ignore ParseOptions.NormalizeTests.
+var LioFormats = []string{"lio2024", "lio2023", "lio-legacy"}
+
+// Test content fixes, see NormalizeTestContent.
+const (
+	FixBOM             = "bom"
+	FixWindows1257     = "windows-1257"
+	FixCRLF            = "crlf"
+	FixTrailingNewline = "trailing newline"
+)
+
+// fixDescriptions describe the fixes as done and as needed.
+var fixDescriptions = map[string][2]string{
+	FixBOM:             {"removed the byte order mark", "has a byte order mark"},
+	FixWindows1257:     {"re-encoded from Windows-1257 to UTF-8", "is encoded in Windows-1257"},
+	FixCRLF:            {"replaced CRLF line endings", "has CRLF line endings"},
+	FixTrailingNewline: {"added a trailing newline", "lacks a trailing newline"},
+}
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// windows1257 maps the bytes 0x80-0xFF of Windows-1257, the Baltic code
+// page, to runes. 0 marks bytes the code page leaves undefined.
+var windows1257 = [128]rune{
+	0x20AC, 0, 0x201A, 0, 0x201E, 0x2026, 0x2020, 0x2021, // 0x80
+	0, 0x2030, 0, 0x2039, 0, 0x00A8, 0x02C7, 0x00B8, // 0x88
+	0, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014, // 0x90
+	0, 0x2122, 0, 0x203A, 0, 0x00AF, 0x02DB, 0, // 0x98
+	0x00A0, 0, 0x00A2, 0x00A3, 0x00A4, 0, 0x00A6, 0x00A7, // 0xA0
+	0x00D8, 0x00A9, 0x0156, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x00C6, // 0xA8
+	0x00B0, 0x00B1, 0x00B2, 0x00B3, 0x00B4, 0x00B5, 0x00B6, 0x00B7, // 0xB0
+	0x00F8, 0x00B9, 0x0157, 0x00BB, 0x00BC, 0x00BD, 0x00BE, 0x00E6, // 0xB8
+	0x0104, 0x012E, 0x0100, 0x0106, 0x00C4, 0x00C5, 0x0118, 0x0112, // 0xC0
+	0x010C, 0x00C9, 0x0179, 0x0116, 0x0122, 0x0136, 0x012A, 0x013B, // 0xC8
+	0x0160, 0x0143, 0x0145, 0x00D3, 0x014C, 0x00D5, 0x00D6, 0x00D7, // 0xD0
+	0x0172, 0x0141, 0x015A, 0x016A, 0x00DC, 0x017B, 0x017D, 0x00DF, // 0xD8
+	0x0105, 0x012F, 0x0101, 0x0107, 0x00E4, 0x00E5, 0x0119, 0x0113, // 0xE0
+	0x010D, 0x00E9, 0x017A, 0x0117, 0x0123, 0x0137, 0x012B, 0x013C, // 0xE8
+	0x0161, 0x0144, 0x0146, 0x00F3, 0x014D, 0x00F5, 0x00F6, 0x00F7, // 0xF0
+	0x0173, 0x0142, 0x015B, 0x016B, 0x00FC, 0x017C, 0x017E, 0x02D9, // 0xF8
+}
+
+// decodeWindows1257 converts Windows-1257 text to UTF-8. It fails on
+// bytes the code page leaves undefined, which means the text is in some
+// other encoding or is not text at all.
+func decodeWindows1257(content []byte) ([]byte, bool) {
+	res := make([]byte, 0, len(content)+len(content)/4)
+	for _, b := range content {
+		if b < 0x80 {
+			res = append(res, b)
+			continue
+		}
+		r := windows1257[b-0x80]
+		if r == 0 {
+			return nil, false
+		}
+		res = utf8.AppendRune(res, r)
+	}
+	return res, true
+}
+
+// NormalizeTestContent removes a UTF-8 byte order mark, re-encodes content
+// that is not valid UTF-8 from Windows-1257, replaces CRLF line endings
+// with LF and makes non-empty content end with a newline. It returns the
+// result and the fixes applied; content itself is not modified. An error
+// is returned for content that is neither UTF-8 nor Windows-1257, along
+// with the content as it was.
+func NormalizeTestContent(content []byte) ([]byte, []string, error) {
+	fixes := []string{}
+	res := content
+
+	if bytes.HasPrefix(res, utf8BOM) {
+		res = res[len(utf8BOM):]
+		fixes = append(fixes, FixBOM)
+	}
+
+	if !utf8.Valid(res) {
+		decoded, ok := decodeWindows1257(res)
+		if !ok {
+			return content, nil, fmt.Errorf("neither UTF-8 nor Windows-1257")
+		}
+		res = decoded
+		fixes = append(fixes, FixWindows1257)
+	}
+
+	if bytes.Contains(res, []byte("\r\n")) {
+		res = bytes.ReplaceAll(res, []byte("\r\n"), []byte("\n"))
+		fixes = append(fixes, FixCRLF)
+	}
+
+	if len(res) > 0 && res[len(res)-1] != '\n' {
+		res = append(res[:len(res):len(res)], '\n')
+		fixes = append(fixes, FixTrailingNewline)
+	}
+
+	return res, fixes, nil
+}
+
+// NormalizeLioTests normalises the inputs and answers of the tests in
+// place, see NormalizeTestContent. It returns a warning for every test
+// that was changed or, with NormalizeStrict, that would be changed. With
+// NormalizeOff it does nothing.
+func NormalizeLioTests(tests []LioTest, mode string) ([]string, error) {
+	switch mode {
+	case NormalizeOff, "":
+		return nil, nil
+	case NormalizeFix, NormalizeStrict:
+	default:
+		return nil, fmt.Errorf("unsupported test normalisation mode %q, supported modes: %s",
+			mode, strings.Join(NormalizeModes, ", "))
+	}
+
+	warnings := []string{}
+	for i := range tests {
+		for _, file := range []struct {
+			name    string
+			content *[]byte
+		}{
+			{"input", &tests[i].Input},
+			{"answer", &tests[i].Answer},
+		} {
+			normalized, fixes, err := NormalizeTestContent(*file.content)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s of test %s is %v, left as is",
+					file.name, lioTestLabel(tests[i]), err))
+				continue
+			}
+			if len(fixes) == 0 {
+				continue
+			}
+
+			descriptions := []string{}
+			for _, fix := range fixes {
+				if mode == NormalizeStrict {
+					descriptions = append(descriptions, fixDescriptions[fix][1])
+				} else {
+					descriptions = append(descriptions, fixDescriptions[fix][0])
+				}
+			}
+			if mode == NormalizeStrict {
+				warnings = append(warnings, fmt.Sprintf("%s of test %s %s",
+					file.name, lioTestLabel(tests[i]), strings.Join(descriptions, ", ")))
+				continue
+			}
+			*file.content = normalized
+			warnings = append(warnings, fmt.Sprintf("%s of test %s: %s",
+				file.name, lioTestLabel(tests[i]), strings.Join(descriptions, ", ")))
+		}
+	}
+	return warnings, nil
+}
+
+// normalizeLioTests is NormalizeLioTests as a stage of parsing.
+func normalizeLioTests(tests []LioTest, mode string, timings *StageTimings) ([]string, error) {
+	if mode == NormalizeOff || mode == "" {
+		return nil, nil
+	}
+	done := timings.Start("normalize")
+	defer done()
+	return NormalizeLioTests(tests, mode)
+}
+
+// lioTestLabel names a test by its group and letter, e.g. 3b.
+func lioTestLabel(t LioTest) string {
+	return fmt.Sprintf("%d%s", t.TestGroup, string(rune(t.NoInTestGroup+int('a')-1)))
+}
@@ -0,0 +1,95 @@
+package internal_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTestContent(t *testing.T) {
+	cases := []struct {
+		content string
+		want    string
+		fixes   []string
+	}{
+		{"1 2\n", "1 2\n", []string{}},
+		{"", "", []string{}},
+		{"1 2\r\n3\r\n", "1 2\n3\n", []string{internal.FixCRLF}},
+		{"\xef\xbb\xbf1 2\n", "1 2\n", []string{internal.FixBOM}},
+		{"1 2", "1 2\n", []string{internal.FixTrailingNewline}},
+		// "Rīga ūdens" in Windows-1257
+		{"R\xeega \xfbdens\r\n", "Rīga ūdens\n", []string{internal.FixWindows1257, internal.FixCRLF}},
+		{"lone\rcarriage return\n", "lone\rcarriage return\n", []string{}},
+	}
+	for _, c := range cases {
+		content := []byte(c.content)
+		got, fixes, err := internal.NormalizeTestContent(content)
+		require.NoError(t, err, c.content)
+		assert.Equal(t, c.want, string(got), c.content)
+		assert.Equal(t, c.fixes, fixes, c.content)
+		assert.Equal(t, c.content, string(content), "content must not be modified")
+	}
+
+	// 0x81 is undefined in Windows-1257
+	_, _, err := internal.NormalizeTestContent([]byte("\x81\x00"))
+	assert.Error(t, err)
+}
+
+func TestNormalizeLioTests(t *testing.T) {
+	newTests := func() []internal.LioTest {
+		return []internal.LioTest{
+			{TestGroup: 1, NoInTestGroup: 1, Input: []byte("1\r\n"), Answer: []byte("2\n")},
+			{TestGroup: 1, NoInTestGroup: 2, Input: []byte("3\n"), Answer: []byte("4\n")},
+			{TestGroup: 2, NoInTestGroup: 1, Input: []byte("5\n"), Answer: []byte("\xef\xbb\xbf\xe2")},
+		}
+	}
+
+	tests := newTests()
+	warnings, err := internal.NormalizeLioTests(tests, internal.NormalizeStrict)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"input of test 1a has CRLF line endings",
+		"answer of test 2a has a byte order mark, is encoded in Windows-1257, lacks a trailing newline",
+	}, warnings)
+	assert.Equal(t, newTests(), tests)
+
+	warnings, err = internal.NormalizeLioTests(tests, internal.NormalizeFix)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"input of test 1a: replaced CRLF line endings",
+		"answer of test 2a: removed the byte order mark, re-encoded from Windows-1257 to UTF-8, added a trailing newline",
+	}, warnings)
+	assert.Equal(t, "1\n", string(tests[0].Input))
+	assert.Equal(t, "ā\n", string(tests[2].Answer))
+
+	_, err = internal.NormalizeLioTests(tests, "sometimes")
+	assert.Error(t, err)
+}
+
+func TestParseTaskFSNormalizeTests(t *testing.T) {
+	fsys := fstest.MapFS{
+		"testi/kp.i1": &fstest.MapFile{Data: []byte("1\r\n")},
+		"testi/kp.o1": &fstest.MapFile{Data: []byte("1")},
+	}
+	imp, err := internal.GetImporter("lio-legacy")
+	require.NoError(t, err)
+
+	timings := &internal.StageTimings{}
+	task, warnings, err := internal.ParseTaskFS(context.Background(), imp, fsys, "kp",
+		internal.ParseOptions{NormalizeTests: internal.NormalizeFix}, timings)
+	require.NoError(t, err)
+	assert.Contains(t, warnings, "input of test 1a: replaced CRLF line endings")
+	assert.Contains(t, warnings, "answer of test 1a: added a trailing newline")
+	assert.Equal(t, "1\n", string(task.GetTestsSortedByID()[0].Input))
+	assert.Equal(t, "1\n", string(task.GetTestsSortedByID()[0].Answer))
+
+	stages := []string{}
+	for _, s := range timings.Stages {
+		stages = append(stages, s.Stage)
+	}
+	assert.Contains(t, stages, "normalize")
+}
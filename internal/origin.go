@@ -0,0 +1,250 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"gopkg.in/yaml.v2"
+)
+
+// OlympiadConfigFilename is the name of the olympiad-level config file
+// that is looked up in the task directory and its parents.
+const OlympiadConfigFilename = "olympiad.yaml"
+
+const (
+	StageSchool    = "school"
+	StageRegional  = "regional"
+	StageNational  = "national"
+	StageSelection = "selection"
+)
+
+// OriginStages lists the stages of an olympiad an Origin may name.
+var OriginStages = []string{StageSchool, StageRegional, StageNational, StageSelection}
+
+// Origin describes the competition a task was used in.
+type Origin struct {
+	Olympiad string `yaml:"olympiad" toml:"olympiad" json:"olympiad"`
+	Year     int    `yaml:"year" toml:"year,omitempty" json:"year,omitempty"`
+	Stage    string `yaml:"stage" toml:"stage,omitempty" json:"stage,omitempty"`
+	Notes    string `yaml:"notes" toml:"notes,omitempty" json:"notes,omitempty"`
+}
+
+// String joins the fields of the origin that are set, e.g. "LIO 2024
+// national".
+func (o Origin) String() string {
+	fields := []string{}
+	if o.Olympiad != "" {
+		fields = append(fields, o.Olympiad)
+	}
+	if o.Year != 0 {
+		fields = append(fields, strconv.Itoa(o.Year))
+	}
+	if o.Stage != "" {
+		fields = append(fields, o.Stage)
+	}
+	if o.Notes != "" {
+		fields = append(fields, o.Notes)
+	}
+	return strings.Join(fields, " ")
+}
+
+// Merge fills the empty fields of o with the values from other.
+func (o Origin) Merge(other Origin) Origin {
+	if o.Olympiad == "" {
+		o.Olympiad = other.Olympiad
+	}
+	if o.Year == 0 {
+		o.Year = other.Year
+	}
+	if o.Stage == "" {
+		o.Stage = other.Stage
+	}
+	if o.Notes == "" {
+		o.Notes = other.Notes
+	}
+	return o
+}
+
+var lioYearDirRegexp = regexp.MustCompile(`^(?i)lio[_-]?(\d{4})$`)
+
+/*
+lio2024/3.kārta/kp -> {Olympiad: "LIO", Year: 2024, Stage: "national"}
+
+Only the directories from the olympiad root down are looked at: the
+nearest lio2024-like directory or directory with an olympiad config. A
+path without such a root tells nothing.
+*/
+func InferOriginFromPath(dirPath string) Origin {
+	res := Origin{}
+
+	absPath, err := filepath.Abs(dirPath)
+	if err != nil {
+		absPath = dirPath
+	}
+
+	// directories above the root, such as a checkout in /tmp/skola, are
+	// not part of the archive
+	parts := []string{}
+	for dir := absPath; ; {
+		parts = append([]string{filepath.Base(dir)}, parts...)
+		if lioYearDirRegexp.MatchString(filepath.Base(dir)) || hasOlympiadConfig(dir) {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return res
+		}
+		dir = parent
+	}
+
+	for _, part := range parts {
+		if m := lioYearDirRegexp.FindStringSubmatch(part); m != nil {
+			year, err := strconv.Atoi(m[1])
+			if err == nil {
+				res.Olympiad = "LIO"
+				res.Year = year
+			}
+			continue
+		}
+		if stage := stageFromDirName(part); stage != "" {
+			res.Stage = stage
+		}
+	}
+
+	return res
+}
+
+func hasOlympiadConfig(dirPath string) bool {
+	_, err := os.Stat(filepath.Join(dirPath, OlympiadConfigFilename))
+	return err == nil
+}
+
+func stageFromDirName(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasPrefix(name, "1.kārta"), strings.HasPrefix(name, "1.karta"),
+		strings.HasPrefix(name, "skolas"):
+		return StageSchool
+	case strings.HasPrefix(name, "2.kārta"), strings.HasPrefix(name, "2.karta"),
+		strings.HasPrefix(name, "novada"):
+		return StageRegional
+	case strings.HasPrefix(name, "3.kārta"), strings.HasPrefix(name, "3.karta"),
+		strings.HasPrefix(name, "valsts"):
+		return StageNational
+	case strings.HasPrefix(name, "atlase"):
+		return StageSelection
+	}
+	return ""
+}
+
+// ReadOlympiadConfig looks for the olympiad config file in the task
+// directory and its parents. The nearest one is used. If none is found,
+// an empty origin is returned.
+func ReadOlympiadConfig(taskDirPath string) (Origin, error) {
+	dir, err := filepath.Abs(taskDirPath)
+	if err != nil {
+		return Origin{}, fmt.Errorf("failed to resolve %s: %w", taskDirPath, err)
+	}
+
+	for {
+		configPath := filepath.Join(dir, OlympiadConfigFilename)
+		content, err := os.ReadFile(configPath)
+		if err == nil {
+			return parseOlympiadConfig(content, configPath)
+		}
+		if !os.IsNotExist(err) {
+			return Origin{}, fmt.Errorf("failed to read %s: %w", configPath, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return Origin{}, nil
+		}
+		dir = parent
+	}
+}
+
+// ReadOlympiadConfigFS reads the olympiad config file at the root of fsys.
+// Unlike ReadOlympiadConfig, it has no parents to look in.
+func ReadOlympiadConfigFS(fsys fs.FS) (Origin, error) {
+	content, err := fs.ReadFile(fsys, OlympiadConfigFilename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Origin{}, nil
+	}
+	if err != nil {
+		return Origin{}, fmt.Errorf("failed to read %s: %w", OlympiadConfigFilename, err)
+	}
+	return parseOlympiadConfig(content, OlympiadConfigFilename)
+}
+
+func parseOlympiadConfig(content []byte, configPath string) (Origin, error) {
+	res := Origin{}
+	err := yaml.UnmarshalStrict(content, &res)
+	if err != nil {
+		return Origin{}, fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+	if res.Stage != "" && !slices.Contains(OriginStages, res.Stage) {
+		return Origin{}, importErrorf(CodeInvalidSyntax, configPath, 0,
+			"unknown stage %q, expected one of: %s", res.Stage, strings.Join(OriginStages, ", "))
+	}
+	return res, nil
+}
+
+// ResolveOrigin completes the given origin, such as one from command line
+// flags, with the olympiad config, the source path and the olympiad the
+// task names itself, in that order of precedence.
+func ResolveOrigin(origin Origin, sourcePath string, task *fstaskparser.Task) (Origin, error) {
+	// an archive stands in for the task directory it contains
+	originPath := TrimArchiveExtension(sourcePath)
+	olympiadConfig, err := ReadOlympiadConfig(originPath)
+	if err != nil {
+		return Origin{}, fmt.Errorf("failed to read olympiad config: %w", err)
+	}
+
+	return origin.
+		Merge(olympiadConfig).
+		Merge(InferOriginFromPath(originPath)).
+		Merge(Origin{Olympiad: task.GetOriginOlympiad()}), nil
+}
+
+// WriteOriginToProblemToml appends an [origin] table to the problem.toml
+// of a stored task. The fs task format only knows the olympiad name,
+// so year, stage and notes are kept in a table of their own.
+func WriteOriginToProblemToml(taskDirPath string, origin Origin) error {
+	problemTomlPath := filepath.Join(taskDirPath, "problem.toml")
+
+	content, err := os.ReadFile(problemTomlPath)
+	if err != nil {
+		return fmt.Errorf("failed to read problem.toml: %w", err)
+	}
+
+	buf := bytes.NewBuffer(content)
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	err = toml.NewEncoder(buf).SetIndentTables(true).Encode(struct {
+		Origin Origin `toml:"origin"`
+	}{origin})
+	if err != nil {
+		return fmt.Errorf("failed to encode origin: %w", err)
+	}
+
+	err = os.WriteFile(problemTomlPath, buf.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write problem.toml: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferOriginFromPath(t *testing.T) {
+	origin := internal.InferOriginFromPath(filepath.Join("archive", "lio2024", "3.kārta", "kp"))
+	assert.Equal(t, internal.Origin{
+		Olympiad: "LIO",
+		Year:     2024,
+		Stage:    internal.StageNational,
+	}, origin)
+
+	origin = internal.InferOriginFromPath(filepath.Join("archive", "LIO2019", "atlase", "kp"))
+	assert.Equal(t, internal.Origin{
+		Olympiad: "LIO",
+		Year:     2019,
+		Stage:    internal.StageSelection,
+	}, origin)
+
+	origin = internal.InferOriginFromPath(filepath.Join("tasks", "kp"))
+	assert.Equal(t, internal.Origin{}, origin)
+}
+
+func TestInferOriginFromPathIgnoresAncestors(t *testing.T) {
+	// a checkout in a directory named like a stage
+	root := filepath.Join(t.TempDir(), "valsts")
+	assert.Equal(t, internal.Origin{}, internal.InferOriginFromPath(filepath.Join(root, "uzdevumi", "kp")))
+
+	origin := internal.InferOriginFromPath(filepath.Join(root, "lio2024", "kp"))
+	assert.Equal(t, internal.Origin{Olympiad: "LIO", Year: 2024}, origin)
+
+	olympiadDir := filepath.Join(root, "olimpiade")
+	require.NoError(t, os.MkdirAll(olympiadDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(olympiadDir, internal.OlympiadConfigFilename), []byte("olympiad: LIO\n"), 0644))
+	origin = internal.InferOriginFromPath(filepath.Join(olympiadDir, "2.kārta", "kp"))
+	assert.Equal(t, internal.Origin{Stage: internal.StageRegional}, origin)
+}
+
+func TestOlympiadConfigUnknownStage(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, internal.OlympiadConfigFilename), []byte("olympiad: LIO\nstage: final\n"), 0644))
+
+	_, err := internal.ReadOlympiadConfig(dir)
+	assert.ErrorIs(t, err, internal.ErrInvalidSyntax)
+	assert.ErrorContains(t, err, `unknown stage "final"`)
+}
+
+func TestOlympiadConfigPrecedence(t *testing.T) {
+	root := t.TempDir()
+	taskDir := filepath.Join(root, "lio2024", "2.kārta", "kp")
+	require.NoError(t, os.MkdirAll(taskDir, 0755))
+
+	config := "olympiad: LIO\nstage: regional\nnotes: Novada olimpiāde\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, "lio2024", internal.OlympiadConfigFilename), []byte(config), 0644))
+
+	fromConfig, err := internal.ReadOlympiadConfig(taskDir)
+	require.NoError(t, err)
+
+	origin := internal.Origin{Notes: "no. 3"}.
+		Merge(fromConfig).
+		Merge(internal.InferOriginFromPath(taskDir))
+
+	assert.Equal(t, internal.Origin{
+		Olympiad: "LIO",
+		Year:     2024,
+		Stage:    internal.StageRegional,
+		Notes:    "no. 3",
+	}, origin)
+}
+
+func TestOriginString(t *testing.T) {
+	assert.Equal(t, "LIO 2024 national", internal.Origin{Olympiad: "LIO", Year: 2024, Stage: internal.StageNational}.String())
+	assert.Equal(t, "LIO", internal.Origin{Olympiad: "LIO"}.String())
+	assert.Equal(t, "", internal.Origin{}.String())
+}
@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+)
+
+// UsedFilesLister is implemented by importers that can tell which files of
+// the task directory they read, so that the others can be reported as
+// ignored.
+type UsedFilesLister interface {
+	// UsedFiles returns the paths of the files in fsys, which holds the
+	// task directory at its root.
+	UsedFiles(fsys fs.FS) ([]string, error)
+}
+
+// IgnoredFiles returns the files of the directory that are not used.
+func IgnoredFiles(dirPath string, used []string) ([]string, error) {
+	files, err := listFilesSorted(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dirPath, err)
+	}
+	isUsed := map[string]bool{}
+	for _, f := range used {
+		isUsed[f] = true
+	}
+	res := []string{}
+	for _, f := range files {
+		if !isUsed[f] {
+			res = append(res, f)
+		}
+	}
+	return res, nil
+}
+
+// ImportPlan describes what an import would write, without writing it.
+type ImportPlan struct {
+	Source               string          `json:"source"`
+	Format               string          `json:"format"`
+	Target               string          `json:"target"`
+	Output               string          `json:"output,omitempty"`
+	TaskName             string          `json:"task_name"`
+	TimeLimitSeconds     float64         `json:"time_limit_seconds"`
+	MemoryLimitMegabytes int             `json:"memory_limit_megabytes"`
+	Examples             int             `json:"examples"`
+	Tests                int             `json:"tests"`
+	TestGroups           []PlanTestGroup `json:"test_groups"`
+	Subtasks             []PlanSubtask   `json:"subtasks"`
+	TotalPoints          int             `json:"total_points"`
+	Statements           []string        `json:"statements"`
+	Checker              string          `json:"checker,omitempty"`
+	Validator            string          `json:"validator,omitempty"`
+	Graders              []string        `json:"graders,omitempty"`
+	Origin               Origin          `json:"origin"`
+	Warnings             []string        `json:"warnings"`
+	// IgnoredFiles is nil if the source format cannot tell which files
+	// it reads.
+	IgnoredFiles []string `json:"ignored_files"`
+}
+
+// PlanTestGroup is a test group of an ImportPlan.
+type PlanTestGroup struct {
+	ID      int  `json:"id"`
+	Points  int  `json:"points"`
+	Public  bool `json:"public"`
+	Subtask int  `json:"subtask"`
+	Tests   int  `json:"tests"`
+}
+
+// PlanSubtask is a subtask of an ImportPlan.
+type PlanSubtask struct {
+	Subtask int `json:"subtask"`
+	Points  int `json:"points"`
+}
+
+// NewImportPlan fills in the parts of the plan that come from the task.
+func NewImportPlan(task *fstaskparser.Task) ImportPlan {
+	res := ImportPlan{
+		TaskName:             task.GetTaskName(),
+		TimeLimitSeconds:     task.GetCPUTimeLimitInSeconds(),
+		MemoryLimitMegabytes: task.GetMemoryLimitInMegabytes(),
+		Examples:             len(task.GetExamples()),
+		Tests:                len(task.GetTestsSortedByID()),
+		TestGroups:           []PlanTestGroup{},
+		Subtasks:             []PlanSubtask{},
+		Statements:           []string{},
+		Warnings:             []string{},
+	}
+
+	groupIDs := append([]int{}, task.GetTestGroupIDs()...)
+	sort.Ints(groupIDs)
+	subtaskPoints := map[int]int{}
+	for _, id := range groupIDs {
+		g := task.GetInfoOnTestGroup(id)
+		res.TestGroups = append(res.TestGroups, PlanTestGroup{
+			ID:      id,
+			Points:  g.Points,
+			Public:  g.Public,
+			Subtask: g.Subtask,
+			Tests:   len(g.TestIDs),
+		})
+		subtaskPoints[g.Subtask] += g.Points
+		res.TotalPoints += g.Points
+	}
+	for subtask, points := range subtaskPoints {
+		res.Subtasks = append(res.Subtasks, PlanSubtask{Subtask: subtask, Points: points})
+	}
+	sort.Slice(res.Subtasks, func(i, j int) bool {
+		return res.Subtasks[i].Subtask < res.Subtasks[j].Subtask
+	})
+
+	for _, st := range task.GetAllPDFStatements() {
+		res.Statements = append(res.Statements, "pdf:"+st.Language)
+	}
+	for _, st := range task.GetMarkdownStatements() {
+		lang := "unknown"
+		if st.Language != nil {
+			lang = *st.Language
+		}
+		res.Statements = append(res.Statements, "md:"+lang)
+	}
+	sort.Strings(res.Statements)
+
+	return res
+}
+
+// WriteText writes the plan in a human-readable form.
+func (p ImportPlan) WriteText(w io.Writer) error {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "Source:       %s (%s)\n", p.Source, p.Format)
+	output := p.Output
+	if output == "" {
+		output = "-"
+	}
+	fmt.Fprintf(&b, "Output:       %s (%s)\n", output, p.Target)
+	fmt.Fprintf(&b, "Task:         %s\n", p.TaskName)
+	fmt.Fprintf(&b, "Limits:       %gs, %d MB\n", p.TimeLimitSeconds, p.MemoryLimitMegabytes)
+	fmt.Fprintf(&b, "Examples:     %d\n", p.Examples)
+	fmt.Fprintf(&b, "Tests:        %d in %d groups\n", p.Tests, len(p.TestGroups))
+	for _, g := range p.TestGroups {
+		public := ""
+		if g.Public {
+			public = ", public"
+		}
+		fmt.Fprintf(&b, "  group %3d:  %d tests, %d points, subtask %d%s\n",
+			g.ID, g.Tests, g.Points, g.Subtask, public)
+	}
+	fmt.Fprintf(&b, "Points:       %d\n", p.TotalPoints)
+	for _, s := range p.Subtasks {
+		fmt.Fprintf(&b, "  subtask %d:  %d points\n", s.Subtask, s.Points)
+	}
+	fmt.Fprintf(&b, "Statements:   %s\n", strings.Join(p.Statements, ", "))
+	if p.Checker != "" {
+		fmt.Fprintf(&b, "Checker:      %s\n", p.Checker)
+	}
+	if p.Validator != "" {
+		fmt.Fprintf(&b, "Validator:    %s\n", p.Validator)
+	}
+	if len(p.Graders) > 0 {
+		fmt.Fprintf(&b, "Graders:      %s\n", strings.Join(p.Graders, ", "))
+	}
+	fmt.Fprintf(&b, "Origin:       %s\n", p.Origin)
+	for _, warning := range p.Warnings {
+		fmt.Fprintf(&b, "Warning:      %s\n", warning)
+	}
+	if p.IgnoredFiles == nil {
+		fmt.Fprintf(&b, "Ignored:      unknown for %s\n", p.Format)
+	} else if len(p.IgnoredFiles) == 0 {
+		fmt.Fprintf(&b, "Ignored:      none\n")
+	}
+	for _, f := range p.IgnoredFiles {
+		fmt.Fprintf(&b, "Ignored:      %s\n", f)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,57 @@
+package internal_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportPlan(t *testing.T) {
+	task, err := fstaskparser.NewTask("Kp")
+	require.NoError(t, err)
+	task.SetCPUTimeLimitInSeconds(0.5)
+	task.SetMemoryLimitInMegabytes(256)
+	task.AddExample([]byte("1\n"), []byte("1\n"))
+	a := task.AddTest([]byte("2\n"), []byte("2\n"))
+	b := task.AddTest([]byte("3\n"), []byte("3\n"))
+	c := task.AddTest([]byte("4\n"), []byte("4\n"))
+	require.NoError(t, task.AddTestGroupWithID(1, 10, true, []int{a, b}, 1))
+	require.NoError(t, task.AddTestGroupWithID(2, 20, false, []int{c}, 1))
+	require.NoError(t, task.AddPDFStatement("lv", []byte("%PDF")))
+
+	plan := internal.NewImportPlan(task)
+	assert.Equal(t, 1, plan.Examples)
+	assert.Equal(t, 3, plan.Tests)
+	assert.Equal(t, []internal.PlanTestGroup{
+		{ID: 1, Points: 10, Public: true, Subtask: 1, Tests: 2},
+		{ID: 2, Points: 20, Public: false, Subtask: 1, Tests: 1},
+	}, plan.TestGroups)
+	assert.Equal(t, []internal.PlanSubtask{{Subtask: 1, Points: 30}}, plan.Subtasks)
+	assert.Equal(t, 30, plan.TotalPoints)
+	assert.Equal(t, []string{"pdf:lv"}, plan.Statements)
+
+	plan.IgnoredFiles = []string{"teksts/kp.typ"}
+	buf := bytes.Buffer{}
+	require.NoError(t, plan.WriteText(&buf))
+	assert.Contains(t, buf.String(), "  group   1:  2 tests, 10 points, subtask 1, public\n")
+	assert.Contains(t, buf.String(), "Ignored:      teksts/kp.typ\n")
+}
+
+func TestIgnoredFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"task.yaml":        "",
+		"testi/tests.zip":  "",
+		"teksts/kp.pdf":    "",
+		"teksts/kp.typ":    "",
+		"riki/checker.cpp": "",
+	})
+
+	ignored, err := internal.IgnoredFiles(dir, []string{"task.yaml", "testi/tests.zip", "teksts/kp.pdf", "metadata.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"riki/checker.cpp", "teksts/kp.typ"}, ignored)
+}
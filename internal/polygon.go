@@ -0,0 +1,523 @@
+package internal
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+)
+
+func init() {
+	RegisterImporter(polygonImporter{})
+}
+
+type polygonImporter struct{}
+
+func (polygonImporter) Name() string { return "polygon" }
+
+func (polygonImporter) Description() string {
+	return "Codeforces Polygon full package with problem.xml and generated tests"
+}
+
+func (polygonImporter) Detect(fsys fs.FS) bool {
+	_, err := fs.Stat(fsys, "problem.xml")
+	return err == nil
+}
+
+func (polygonImporter) Parse(dirPath string) (*fstaskparser.Task, []string, error) {
+	return ParsePolygonPackageDir(dirPath)
+}
+
+func (polygonImporter) ParseFS(ctx context.Context, fsys fs.FS, _ string, _ ParseOptions, timings *StageTimings) (*fstaskparser.Task, []string, error) {
+	return parsePolygonPackageFS(ctx, fsys, timings)
+}
+
+// FindChecker returns nil for the standard testlib checkers, which are
+// left to the default output comparison, see ParsePolygonPackageDir.
+func (polygonImporter) FindChecker(fsys fs.FS) ([]SourceFile, error) {
+	problem, err := readPolygonProblemXml(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	if problem.Checker == nil || strings.HasPrefix(problem.Checker.Name, "std::") {
+		return nil, nil
+	}
+
+	checkerPath, err := polygonPath(problem.Checker.Source.Path)
+	if err != nil {
+		return nil, err
+	}
+	return readSourceFileWithHeaders(fsys, checkerPath)
+}
+
+func (polygonImporter) CheckerKind() string { return CheckerTestlib }
+
+// FindValidator returns the first validator of the package, the others
+// are reported by ParsePolygonPackageDir.
+func (polygonImporter) FindValidator(fsys fs.FS) ([]SourceFile, error) {
+	problem, err := readPolygonProblemXml(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(problem.Validators) == 0 {
+		return nil, nil
+	}
+
+	validatorPath, err := polygonPath(problem.Validators[0].Source.Path)
+	if err != nil {
+		return nil, err
+	}
+	return readSourceFileWithHeaders(fsys, validatorPath)
+}
+
+func readPolygonProblemXml(fsys fs.FS) (PolygonProblemXml, error) {
+	content, err := fs.ReadFile(fsys, "problem.xml")
+	if err != nil {
+		return PolygonProblemXml{}, fmt.Errorf("failed to read problem.xml: %w", err)
+	}
+
+	problem, err := ParsePolygonProblemXml(content)
+	if err != nil {
+		return PolygonProblemXml{}, fmt.Errorf("failed to parse problem.xml: %w", err)
+	}
+	return problem, nil
+}
+
+// polygonPath turns a path of problem.xml into a path of the package
+// directory fs.FS.
+func polygonPath(relPath string) (string, error) {
+	res := path.Clean(relPath)
+	if !fs.ValidPath(res) {
+		return "", fmt.Errorf("path %s is outside of the package directory", relPath)
+	}
+	return res, nil
+}
+
+// readPolygonFile reads the file at a path of problem.xml.
+func readPolygonFile(fsys fs.FS, relPath string) ([]byte, error) {
+	name, err := polygonPath(relPath)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(fsys, name)
+}
+
+type PolygonProblemXml struct {
+	ShortName  string               `xml:"short-name,attr"`
+	Names      []PolygonName        `xml:"names>name"`
+	Statements []PolygonStatement   `xml:"statements>statement"`
+	Testsets   []PolygonTestset     `xml:"judging>testset"`
+	Checker    *PolygonChecker      `xml:"assets>checker"`
+	Interactor *PolygonSourceAsset  `xml:"assets>interactor"`
+	Validators []PolygonSourceAsset `xml:"assets>validators>validator"`
+	Tags       []PolygonTag         `xml:"tags>tag"`
+}
+
+type PolygonName struct {
+	Language string `xml:"language,attr"`
+	Value    string `xml:"value,attr"`
+}
+
+type PolygonStatement struct {
+	Language string `xml:"language,attr"`
+	Path     string `xml:"path,attr"`
+	Type     string `xml:"type,attr"`
+}
+
+type PolygonTestset struct {
+	Name              string         `xml:"name,attr"`
+	TimeLimitMs       int            `xml:"time-limit"`
+	MemoryLimitBytes  int64          `xml:"memory-limit"`
+	TestCount         int            `xml:"test-count"`
+	InputPathPattern  string         `xml:"input-path-pattern"`
+	AnswerPathPattern string         `xml:"answer-path-pattern"`
+	Tests             []PolygonTest  `xml:"tests>test"`
+	Groups            []PolygonGroup `xml:"groups>group"`
+}
+
+type PolygonTest struct {
+	Method string   `xml:"method,attr"`
+	Cmd    string   `xml:"cmd,attr"`
+	Sample bool     `xml:"sample,attr"`
+	Group  string   `xml:"group,attr"`
+	Points *float64 `xml:"points,attr"`
+}
+
+type PolygonGroup struct {
+	Name         string   `xml:"name,attr"`
+	Points       *float64 `xml:"points,attr"`
+	PointsPolicy string   `xml:"points-policy,attr"`
+	Dependencies []struct {
+		Group string `xml:"group,attr"`
+	} `xml:"dependencies>dependency"`
+}
+
+type PolygonSource struct {
+	Path string `xml:"path,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type PolygonSourceAsset struct {
+	Source PolygonSource `xml:"source"`
+}
+
+type PolygonChecker struct {
+	Name   string        `xml:"name,attr"`
+	Type   string        `xml:"type,attr"`
+	Source PolygonSource `xml:"source"`
+}
+
+type PolygonTag struct {
+	Value string `xml:"value,attr"`
+}
+
+// polygonStdCheckers are the standard testlib checkers that compare the
+// output the same way programme.lv does when a task has no checker.
+var polygonStdCheckers = map[string]bool{
+	"std::wcmp.cpp":   true,
+	"std::lcmp.cpp":   true,
+	"std::fcmp.cpp":   true,
+	"std::hcmp.cpp":   true,
+	"std::ncmp.cpp":   true,
+	"std::nyesno.cpp": true,
+	"std::yesno.cpp":  true,
+}
+
+var polygonLanguageCodes = map[string]string{
+	"latvian":    "lv",
+	"english":    "en",
+	"russian":    "ru",
+	"lithuanian": "lt",
+	"estonian":   "et",
+	"ukrainian":  "uk",
+}
+
+func ParsePolygonProblemXml(content []byte) (res PolygonProblemXml, err error) {
+	err = xml.Unmarshal(content, &res)
+	return
+}
+
+// ParsePolygonPackageDir parses an unpacked Polygon full package. Only the
+// tests testset is imported and its tests must already be materialised in
+// the package. Polygon groups become test groups; groups scored per test
+// are split into one test group per test. The checker and the validator
+// are not part of the task, see FindChecker and FindValidator.
+func ParsePolygonPackageDir(dirPath string) (*fstaskparser.Task, []string, error) {
+	return ParsePolygonPackageFS(os.DirFS(dirPath))
+}
+
+// ParsePolygonPackageFS is ParsePolygonPackageDir for the package at the
+// root of fsys.
+func ParsePolygonPackageFS(fsys fs.FS) (*fstaskparser.Task, []string, error) {
+	return parsePolygonPackageFS(context.Background(), fsys, nil)
+}
+
+func parsePolygonPackageFS(ctx context.Context, fsys fs.FS, timings *StageTimings) (*fstaskparser.Task, []string, error) {
+	warnings := []string{}
+
+	done := timings.Start("xml parse")
+	problem, err := readPolygonProblemXml(fsys)
+	done()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	task, err := fstaskparser.NewTask(polygonTaskName(problem))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create new task: %w", err)
+	}
+
+	if problem.Checker != nil && strings.HasPrefix(problem.Checker.Name, "std::") && !polygonStdCheckers[problem.Checker.Name] {
+		warnings = append(warnings, fmt.Sprintf("standard checker %s is replaced by the default output comparison", problem.Checker.Name))
+	}
+
+	if problem.Interactor != nil {
+		// TODO: implement
+		return nil, nil, fmt.Errorf("interactors are not implemented yet (found %s)", problem.Interactor.Source.Path)
+	}
+
+	for _, v := range problem.Validators[min(1, len(problem.Validators)):] {
+		warnings = append(warnings, fmt.Sprintf("only the first validator is imported, %s is ignored", v.Source.Path))
+	}
+
+	var testset *PolygonTestset
+	for i := range problem.Testsets {
+		if problem.Testsets[i].Name == "tests" {
+			testset = &problem.Testsets[i]
+		}
+	}
+	if testset == nil {
+		return nil, nil, fmt.Errorf("testset \"tests\" not found in problem.xml")
+	}
+	if len(problem.Testsets) > 1 {
+		warnings = append(warnings, fmt.Sprintf("only the \"tests\" testset is imported, %d others are ignored", len(problem.Testsets)-1))
+	}
+
+	task.SetCPUTimeLimitInSeconds(float64(testset.TimeLimitMs) / 1000)
+	task.SetMemoryLimitInMegabytes(int(testset.MemoryLimitBytes / (1024 * 1024)))
+
+	done = timings.Start("test read")
+	err = addPolygonTests(ctx, task, fsys, *testset, &warnings, timings)
+	done()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	done = timings.Start("statement read")
+	err = addPolygonStatements(task, fsys, problem, &warnings)
+	done()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tags := []string{}
+	for _, tag := range problem.Tags {
+		tags = append(tags, tag.Value)
+	}
+	task.SetProblemTags(tags)
+
+	return task, warnings, nil
+}
+
+func polygonTaskName(problem PolygonProblemXml) string {
+	for _, lang := range []string{"latvian", "english"} {
+		for _, name := range problem.Names {
+			if name.Language == lang {
+				return name.Value
+			}
+		}
+	}
+	if len(problem.Names) > 0 {
+		return problem.Names[0].Value
+	}
+	return problem.ShortName
+}
+
+func addPolygonTests(ctx context.Context, task *fstaskparser.Task, fsys fs.FS, testset PolygonTestset, warnings *[]string, timings *StageTimings) error {
+	if len(testset.Tests) != testset.TestCount {
+		return fmt.Errorf("test count %d does not match the number of tests %d", testset.TestCount, len(testset.Tests))
+	}
+
+	type polygonGroupTests struct {
+		group   PolygonGroup
+		testIDs []int
+		points  []float64
+	}
+	groups := map[string]*polygonGroupTests{}
+	groupOrder := []string{}
+	for _, g := range testset.Groups {
+		groups[g.Name] = &polygonGroupTests{group: g}
+		groupOrder = append(groupOrder, g.Name)
+		if len(g.Dependencies) > 0 {
+			*warnings = append(*warnings, fmt.Sprintf("dependencies of group %s are not imported", g.Name))
+		}
+	}
+
+	var bytesRead int64
+	for i, t := range testset.Tests {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		no := i + 1
+		input, err := readPolygonFile(fsys, fmt.Sprintf(testset.InputPathPattern, no))
+		if err != nil {
+			return fmt.Errorf("failed to read input of test %d: %w", no, err)
+		}
+		answer, err := readPolygonFile(fsys, fmt.Sprintf(testset.AnswerPathPattern, no))
+		if err != nil {
+			return fmt.Errorf("failed to read answer of test %d: %w", no, err)
+		}
+		bytesRead += int64(len(input) + len(answer))
+		timings.TestsRead(no, len(testset.Tests), bytesRead)
+
+		if t.Sample {
+			task.AddExample(input, answer)
+		}
+
+		points := 0.0
+		if t.Points != nil {
+			points = *t.Points
+		}
+
+		// samples without points are only examples, like LIO group 0
+		if t.Sample && points == 0 {
+			g, ok := groups[t.Group]
+			if !ok || g.group.Points == nil || *g.group.Points == 0 {
+				continue
+			}
+		}
+
+		id := task.AddTest(input, answer)
+		task.AssignFilenameToTest(fmt.Sprintf("%03d", no), id)
+
+		g, ok := groups[t.Group]
+		if !ok {
+			g = &polygonGroupTests{group: PolygonGroup{Name: t.Group, PointsPolicy: "each-test"}}
+			groups[t.Group] = g
+			groupOrder = append(groupOrder, t.Group)
+		}
+		g.testIDs = append(g.testIDs, id)
+		g.points = append(g.points, points)
+	}
+
+	totalPoints := 0.0
+	for _, name := range groupOrder {
+		for _, p := range groups[name].points {
+			totalPoints += p
+		}
+		if groups[name].group.Points != nil {
+			totalPoints += *groups[name].group.Points
+		}
+	}
+	if totalPoints == 0 {
+		*warnings = append(*warnings, "the package has no points, all tests are placed in a single group worth 100 points")
+		testIDs := []int{}
+		for _, name := range groupOrder {
+			testIDs = append(testIDs, groups[name].testIDs...)
+		}
+		sort.Ints(testIDs)
+		return task.AddTestGroupWithID(1, 100, false, testIDs, 1)
+	}
+
+	groupID := 0
+	subtask := 0
+	for _, name := range groupOrder {
+		g := groups[name]
+		if len(g.testIDs) == 0 {
+			continue
+		}
+		subtask++
+
+		if g.group.PointsPolicy == "complete-group" {
+			points := 0.0
+			if g.group.Points != nil {
+				points = *g.group.Points
+			}
+			groupID++
+			err := task.AddTestGroupWithID(groupID, polygonPoints(points, warnings), false, g.testIDs, subtask)
+			if err != nil {
+				return fmt.Errorf("failed to add test group: %w", err)
+			}
+			continue
+		}
+
+		for i, id := range g.testIDs {
+			groupID++
+			err := task.AddTestGroupWithID(groupID, polygonPoints(g.points[i], warnings), false, []int{id}, subtask)
+			if err != nil {
+				return fmt.Errorf("failed to add test group: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func polygonPoints(points float64, warnings *[]string) int {
+	rounded := int(points + 0.5)
+	if float64(rounded) != points {
+		*warnings = append(*warnings, fmt.Sprintf("fractional points %s rounded to %d",
+			strconv.FormatFloat(points, 'f', -1, 64), rounded))
+	}
+	return rounded
+}
+
+func addPolygonStatements(task *fstaskparser.Task, fsys fs.FS, problem PolygonProblemXml, warnings *[]string) error {
+	mdStatements := []fstaskparser.MarkdownStatement{}
+
+	for _, st := range problem.Statements {
+		lang, ok := polygonLanguageCodes[st.Language]
+		if !ok {
+			*warnings = append(*warnings, fmt.Sprintf("statement in unknown language %s is not imported", st.Language))
+			continue
+		}
+
+		switch st.Type {
+		case "application/pdf":
+			pdfBytes, err := readPolygonFile(fsys, st.Path)
+			if err != nil {
+				return fmt.Errorf("failed to read PDF statement: %w", err)
+			}
+			err = task.AddPDFStatement(lang, pdfBytes)
+			if err != nil {
+				return fmt.Errorf("failed to add PDF statement: %w", err)
+			}
+		case "application/x-tex":
+			sectionsDir := path.Join("statement-sections", st.Language)
+			md, err := readPolygonStatementSections(fsys, sectionsDir, lang)
+			if err != nil {
+				return err
+			}
+			if md == nil {
+				*warnings = append(*warnings, fmt.Sprintf("LaTeX statement %s has no statement sections, it is not imported", st.Path))
+				continue
+			}
+			*warnings = append(*warnings, fmt.Sprintf("LaTeX statement sections in %s are imported as Markdown without conversion", st.Language))
+			mdStatements = append(mdStatements, *md)
+		}
+	}
+
+	if len(mdStatements) > 0 {
+		task.SetMarkdownStatements(mdStatements)
+	}
+
+	return nil
+}
+
+// readPolygonStatementSections returns nil if the directory does not exist.
+func readPolygonStatementSections(fsys fs.FS, sectionsDir string, lang string) (*fstaskparser.MarkdownStatement, error) {
+	if _, err := fs.Stat(fsys, sectionsDir); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	readSection := func(name string) (*string, error) {
+		content, err := fs.ReadFile(fsys, path.Join(sectionsDir, name+".tex"))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read statement section %s: %w", name, err)
+		}
+		s := strings.TrimSpace(string(content))
+		return &s, nil
+	}
+
+	res := fstaskparser.MarkdownStatement{Language: &lang}
+	for _, section := range []struct {
+		name     string
+		required *string
+		optional **string
+	}{
+		{name: "legend", required: &res.Story},
+		{name: "input", required: &res.Input},
+		{name: "output", required: &res.Output},
+		{name: "notes", optional: &res.Notes},
+		{name: "scoring", optional: &res.Scoring},
+	} {
+		content, err := readSection(section.name)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+		if section.required != nil {
+			*section.required = *content
+		} else {
+			*section.optional = content
+		}
+	}
+
+	return &res, nil
+}
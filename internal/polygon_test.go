@@ -0,0 +1,132 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const polygonProblemXml = `<?xml version="1.0" encoding="utf-8" standalone="no"?>
+<problem revision="3" short-name="aplusb">
+    <names>
+        <name language="english" value="A plus B"/>
+        <name language="latvian" value="A plus B (lv)"/>
+    </names>
+    <statements>
+        <statement language="latvian" path="statements/.pdf/latvian/problem.pdf" type="application/pdf"/>
+    </statements>
+    <judging>
+        <testset name="tests">
+            <time-limit>2000</time-limit>
+            <memory-limit>268435456</memory-limit>
+            <test-count>4</test-count>
+            <input-path-pattern>tests/%02d</input-path-pattern>
+            <answer-path-pattern>tests/%02d.a</answer-path-pattern>
+            <tests>
+                <test method="manual" sample="true" group="0"/>
+                <test method="generated" cmd="gen 1" group="1"/>
+                <test method="generated" cmd="gen 2" group="1"/>
+                <test method="generated" cmd="gen 3" group="2" points="30"/>
+            </tests>
+            <groups>
+                <group name="0" points="0" points-policy="each-test"/>
+                <group name="1" points="70" points-policy="complete-group"/>
+                <group name="2" points-policy="each-test"/>
+            </groups>
+        </testset>
+    </judging>
+    <assets>
+        <checker name="std::wcmp.cpp" type="testlib">
+            <source path="files/check.cpp" type="cpp.g++17"/>
+        </checker>
+        <validators>
+            <validator>
+                <source path="files/val.cpp" type="cpp.g++17"/>
+            </validator>
+        </validators>
+    </assets>
+    <tags>
+        <tag value="math"/>
+    </tags>
+</problem>
+`
+
+func TestParsePolygonPackageDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"problem.xml":                         polygonProblemXml,
+		"statements/.pdf/latvian/problem.pdf": "%PDF",
+		"tests/01":                            "1 2\n",
+		"tests/01.a":                          "3\n",
+		"tests/02":                            "2 2\n",
+		"tests/02.a":                          "4\n",
+		"tests/03":                            "3 2\n",
+		"tests/03.a":                          "5\n",
+		"tests/04":                            "4 2\n",
+		"tests/04.a":                          "6\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	imp, err := internal.DetectImporter(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "polygon", imp.Name())
+
+	task, warnings, err := internal.ParsePolygonPackageDir(dir)
+	require.NoError(t, err)
+
+	assert.Empty(t, warnings)
+	assert.Equal(t, "A plus B (lv)", task.GetTaskName())
+	assert.Equal(t, 2.0, task.GetCPUTimeLimitInSeconds())
+	assert.Equal(t, 256, task.GetMemoryLimitInMegabytes())
+	assert.Equal(t, []string{"math"}, task.GetProblemTags())
+	assert.Len(t, task.GetExamples(), 1)
+	assert.Len(t, task.GetTestsSortedByID(), 3)
+
+	require.Equal(t, []int{1, 2}, task.GetTestGroupIDs())
+	assert.Equal(t, 70, task.GetInfoOnTestGroup(1).Points)
+	assert.Len(t, task.GetInfoOnTestGroup(1).TestIDs, 2)
+	assert.Equal(t, 30, task.GetInfoOnTestGroup(2).Points)
+
+	pdf, err := task.GetPDFStatement("lv")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("%PDF"), pdf)
+}
+
+func TestPolygonCheckerAndValidator(t *testing.T) {
+	fsys := fstest.MapFS{
+		"problem.xml": &fstest.MapFile{Data: []byte(strings.Replace(polygonProblemXml,
+			`name="std::wcmp.cpp"`, `name="check.cpp"`, 1))},
+		"files/check.cpp": &fstest.MapFile{Data: []byte("int main() {}\n")},
+		"files/val.cpp":   &fstest.MapFile{Data: []byte("int main() {}\n")},
+		"files/testlib.h": &fstest.MapFile{Data: []byte("\n")},
+	}
+	imp, err := internal.GetImporter("polygon")
+	require.NoError(t, err)
+
+	checker, err := imp.(internal.CheckerFinder).FindChecker(fsys)
+	require.NoError(t, err)
+	require.Len(t, checker, 2)
+	assert.Equal(t, "check.cpp", checker[0].Filename)
+	assert.Equal(t, "testlib.h", checker[1].Filename)
+
+	validator, err := imp.(internal.ValidatorFinder).FindValidator(fsys)
+	require.NoError(t, err)
+	require.Len(t, validator, 2)
+	assert.Equal(t, "val.cpp", validator[0].Filename)
+
+	// standard checkers are left to the default output comparison
+	fsys["problem.xml"] = &fstest.MapFile{Data: []byte(polygonProblemXml)}
+	checker, err = imp.(internal.CheckerFinder).FindChecker(fsys)
+	require.NoError(t, err)
+	assert.Nil(t, checker)
+}
@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"io/fs"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+)
+
+func init() {
+	RegisterImporter(proglvImporter{})
+}
+
+// proglvImporter reads tasks this tool has already imported, so that they
+// can be compared or exported to another format.
+type proglvImporter struct{}
+
+func (proglvImporter) Name() string { return "proglv" }
+
+func (proglvImporter) Description() string {
+	return "programme.lv task directory with problem.toml, such as a previous import"
+}
+
+func (proglvImporter) Detect(fsys fs.FS) bool {
+	_, err := fs.Stat(fsys, "problem.toml")
+	return err == nil
+}
+
+func (proglvImporter) Parse(dirPath string) (*fstaskparser.Task, []string, error) {
+	task, err := fstaskparser.Read(dirPath)
+	return task, nil, err
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Program is a compiled task program, such as a validator, a generator
+// or a solution, see CompileProgram.
+type Program struct {
+	dir     string
+	command []string
+}
+
+// CompileProgram writes the source and its headers to a temporary
+// directory and compiles it: C++ with g++, C with gcc. Python programs
+// are run with python3 as they are. Close removes the directory.
+func CompileProgram(ctx context.Context, files []SourceFile) (*Program, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no program source")
+	}
+
+	dir, err := os.MkdirTemp("", "lio-task-program")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tmp directory: %w", err)
+	}
+	p := &Program{dir: dir}
+
+	for _, f := range files {
+		err = os.WriteFile(filepath.Join(dir, f.Filename), f.Content, 0644)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("failed to write %s: %w", f.Filename, err)
+		}
+	}
+
+	source := files[0].Filename
+	binary := filepath.Join(dir, "program")
+	var compile []string
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".cpp", ".cc", ".cxx":
+		compile = []string{"g++", "-std=c++17", "-O2", "-o", binary, source}
+		p.command = []string{binary}
+	case ".c":
+		compile = []string{"gcc", "-O2", "-o", binary, source, "-lm"}
+		p.command = []string{binary}
+	case ".py":
+		p.command = []string{"python3", filepath.Join(dir, source)}
+	default:
+		p.Close()
+		return nil, fmt.Errorf("unsupported language of %s, supported: C++, C, Python", source)
+	}
+
+	if compile != nil {
+		cmd := exec.CommandContext(ctx, compile[0], compile[1:]...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("failed to compile %s: %w\n%s", source, err, output)
+		}
+	}
+
+	return p, nil
+}
+
+// Close removes the compiled program.
+func (p *Program) Close() error {
+	return os.RemoveAll(p.dir)
+}
+
+// Run runs the program with the arguments and the input on stdin and
+// returns what it wrote to stdout. A program exiting with a non-zero
+// status makes Run return an *exec.ExitError, whose Stderr holds what the
+// program wrote to stderr.
+func (p *Program) Run(ctx context.Context, args []string, input []byte) ([]byte, error) {
+	args = append(append([]string{}, p.command[1:]...), args...)
+	cmd := exec.CommandContext(ctx, p.command[0], args...)
+	cmd.Dir = p.dir
+	cmd.Stdin = bytes.NewReader(input)
+	return cmd.Output()
+}
@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ReportSchemaVersion is the version of the ImportReport JSON schema.
+// Fields are only ever added within a version; it is increased when a
+// field is removed, renamed or changes its meaning.
+const ReportSchemaVersion = 1
+
+// Task report statuses.
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// ImportReport is the machine-readable report of a run. It holds one
+// entry per imported task.
+type ImportReport struct {
+	SchemaVersion int          `json:"schema_version"`
+	Tasks         []TaskReport `json:"tasks"`
+}
+
+// NewImportReport returns an empty report of the current schema version.
+func NewImportReport() *ImportReport {
+	return &ImportReport{SchemaVersion: ReportSchemaVersion, Tasks: []TaskReport{}}
+}
+
+// Write encodes the report as indented JSON.
+func (r *ImportReport) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// TaskReport is the outcome of importing a single task.
+type TaskReport struct {
+	// Source is the -source path as given.
+	Source string `json:"source"`
+	// Format is the source format, empty if it could not be determined.
+	Format string `json:"format,omitempty"`
+	// Target is the target format.
+	Target string `json:"target"`
+	// Status is "ok" or "failed".
+	Status string `json:"status"`
+	// Output is the path written to, empty if nothing was written.
+	Output string `json:"output,omitempty"`
+	// Warnings are the guesses the importer made, see Importer.Parse.
+	Warnings []string `json:"warnings"`
+	// Errors is the chain of the error that failed the import, outermost
+	// first, each message including the ones after it.
+	Errors []string `json:"errors,omitempty"`
+	// ErrorCode is the stable code of the error that failed the import,
+	// see ErrorCode, empty if the error has none.
+	ErrorCode ErrorCode `json:"error_code,omitempty"`
+	// Stages are the timed stages of the import in the order they ran.
+	Stages []StageTiming `json:"stages"`
+	// DurationMs is the wall time of the whole import in milliseconds.
+	DurationMs float64 `json:"duration_ms"`
+	// Examples and Tests are the numbers of examples and tests read.
+	Examples int `json:"examples"`
+	Tests    int `json:"tests"`
+	// TestBytes is the size of all test inputs and answers.
+	TestBytes int64 `json:"test_bytes"`
+	// OutputBytes is the size of the files written.
+	OutputBytes int64 `json:"output_bytes"`
+}
+
+// ErrorChain returns the messages of the error and of the errors it wraps,
+// outermost first.
+func ErrorChain(err error) []string {
+	res := []string{}
+	for ; err != nil; err = errors.Unwrap(err) {
+		res = append(res, err.Error())
+	}
+	return res
+}
+
+// StageTiming is how long a stage of an import took.
+type StageTiming struct {
+	// Stage is one of "detect", "yaml parse", "unzip", "test read",
+	// "normalize", "statement read", "copy", "parse", "store", "export"
+	// and "archive".
+	Stage      string  `json:"stage"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// StageTimings records the stages of an import, reports its progress and
+// logs diagnostics. A nil *StageTimings records nothing, so that timing
+// stays optional for callers.
+type StageTimings struct {
+	Stages []StageTiming
+	// Progress, if set, is called as stages start and finish and as tests
+	// are read.
+	Progress func(ProgressEvent)
+	// Logger, if set, receives debug messages about what the import does.
+	// Problems are returned as errors or warnings, never only logged.
+	Logger *slog.Logger
+}
+
+// discardLogger drops every message, it stands in for a missing Logger.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+
+// logger returns the logger to write diagnostics to.
+func (t *StageTimings) logger() *slog.Logger {
+	if t == nil || t.Logger == nil {
+		return discardLogger
+	}
+	return t.Logger
+}
+
+// Start begins timing the stage and returns the function that ends it.
+func (t *StageTimings) Start(stage string) func() {
+	if t == nil {
+		return func() {}
+	}
+	t.report(ProgressEvent{Kind: ProgressStageStarted, Stage: stage})
+	start := time.Now()
+	return func() {
+		timing := StageTiming{
+			Stage:      stage,
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		t.Stages = append(t.Stages, timing)
+		t.logger().Debug("stage finished", "stage", stage, "duration_ms", timing.DurationMs)
+		t.report(ProgressEvent{Kind: ProgressStageFinished, Stage: stage})
+	}
+}
+
+// Progress event kinds.
+const (
+	ProgressStageStarted  = "stage started"
+	ProgressStageFinished = "stage finished"
+	ProgressTestsRead     = "tests read"
+)
+
+// ProgressEvent tells how far an import has got.
+type ProgressEvent struct {
+	// Kind is one of the Progress* event kinds.
+	Kind string
+	// Stage is the stage the event belongs to, see StageTiming.
+	Stage string
+	// TestsDone and TestsTotal count the tests read so far and in all.
+	// Only set for ProgressTestsRead.
+	TestsDone  int
+	TestsTotal int
+	// Bytes is the size of the test inputs and answers read so far.
+	// Only set for ProgressTestsRead.
+	Bytes int64
+}
+
+// TestsRead reports that done of total tests, of the given size, are read.
+func (t *StageTimings) TestsRead(done int, total int, bytes int64) {
+	t.report(ProgressEvent{
+		Kind:       ProgressTestsRead,
+		Stage:      "test read",
+		TestsDone:  done,
+		TestsTotal: total,
+		Bytes:      bytes,
+	})
+}
+
+func (t *StageTimings) report(event ProgressEvent) {
+	if t != nil && t.Progress != nil {
+		t.Progress(event)
+	}
+}
+
+// PathSize returns the size of the file, or of all files in the directory.
+func PathSize(path string) (int64, error) {
+	var res int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		res += info.Size()
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	return res, err
+}
@@ -0,0 +1,64 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportReportSchema(t *testing.T) {
+	var nilTimings *internal.StageTimings
+	nilTimings.Start("unzip")() // must not panic
+
+	timings := &internal.StageTimings{}
+	timings.Start("yaml parse")()
+
+	report := internal.NewImportReport()
+	report.Tasks = append(report.Tasks, internal.TaskReport{
+		Source:   "kp",
+		Target:   "proglv",
+		Status:   internal.StatusFailed,
+		Warnings: []string{},
+		Errors:   internal.ErrorChain(fmt.Errorf("failed to parse: %w", fmt.Errorf("bad yaml"))),
+		Stages:   timings.Stages,
+	})
+
+	buf := bytes.Buffer{}
+	require.NoError(t, report.Write(&buf))
+
+	decoded := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, float64(internal.ReportSchemaVersion), decoded["schema_version"])
+
+	task := decoded["tasks"].([]interface{})[0].(map[string]interface{})
+	keys := []string{}
+	for k := range task {
+		keys = append(keys, k)
+	}
+	assert.ElementsMatch(t, []string{
+		"source", "target", "status", "warnings", "errors", "stages",
+		"duration_ms", "examples", "tests", "test_bytes", "output_bytes",
+	}, keys)
+	assert.Equal(t, []interface{}{"failed to parse: bad yaml", "bad yaml"}, task["errors"])
+	assert.Equal(t, "yaml parse", task["stages"].([]interface{})[0].(map[string]interface{})["stage"])
+}
+
+func TestStageTimingsLogger(t *testing.T) {
+	buf := bytes.Buffer{}
+	timings := &internal.StageTimings{
+		Logger: slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+	timings.Start("yaml parse")()
+
+	record := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "DEBUG", record["level"])
+	assert.Equal(t, "stage finished", record["msg"])
+	assert.Equal(t, "yaml parse", record["stage"])
+}
@@ -0,0 +1,226 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+)
+
+// Kinds of TaskDirChange.
+const (
+	ChangeAdded   = "added"
+	ChangeChanged = "changed"
+	ChangeRemoved = "removed"
+)
+
+// TaskDirChange is a file of a stored task that UpdateTaskDir rewrote.
+type TaskDirChange struct {
+	Kind string
+	// Path is slash-separated and relative to the task directory.
+	Path string
+}
+
+// taskDirManagedDirs are the directories fstaskparser, StoreValidator and
+// StoreGraders write in whole. Files in them that the new version of the
+// task does not have are stale, files anywhere else were added by hand and
+// are kept.
+var taskDirManagedDirs = []string{"tests", "examples", "statements/pdf", "statements/md", "assets", "validator", "grader"}
+
+// problemTomlManagedKeys are the problem.toml keys fstaskparser and
+// WriteOriginToProblemToml write, as nested tables of the key names. A nil
+// value is a key written whole. Managed keys that the new version of the
+// task does not have were dropped by omitempty or a change of the task,
+// they are stale and not added by hand.
+var problemTomlManagedKeys = func() map[string]interface{} {
+	keys := tomlStructKeys(reflect.TypeOf(fstaskparser.ProblemTOML{}))
+	keys["origin"] = nil
+	return keys
+}()
+
+func tomlStructKeys(t reflect.Type) map[string]interface{} {
+	keys := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		keys[name] = nil
+		if field.Type.Kind() == reflect.Struct {
+			keys[name] = tomlStructKeys(field.Type)
+		}
+	}
+	return keys
+}
+
+// UpdateTaskDir brings the stored task in destDir up to date with the
+// freshly stored task in srcDir, writing only the files that differ.
+// Keys added by hand to problem.toml are preserved.
+func UpdateTaskDir(srcDir, destDir string) ([]TaskDirChange, error) {
+	srcFiles, err := listFilesSorted(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", srcDir, err)
+	}
+	destFiles, err := listFilesSorted(destDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", destDir, err)
+	}
+
+	changes := []TaskDirChange{}
+	inSrc := map[string]bool{}
+	for _, relPath := range srcFiles {
+		inSrc[relPath] = true
+
+		content, err := os.ReadFile(filepath.Join(srcDir, filepath.FromSlash(relPath)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", relPath, err)
+		}
+
+		destPath := filepath.Join(destDir, filepath.FromSlash(relPath))
+		oldContent, err := os.ReadFile(destPath)
+		kind := ChangeChanged
+		if os.IsNotExist(err) {
+			kind = ChangeAdded
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", relPath, err)
+		} else if relPath == "problem.toml" {
+			content, err = mergeProblemToml(oldContent, content)
+			if err != nil {
+				return nil, err
+			}
+			if content == nil {
+				continue
+			}
+		} else if bytes.Equal(content, oldContent) {
+			continue
+		}
+
+		err = os.MkdirAll(filepath.Dir(destPath), 0755)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", relPath, err)
+		}
+		// the file may be hard-linked to another test, see
+		// LinkDuplicateTestFiles, which must keep its content
+		err = os.Remove(destPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to replace %s: %w", relPath, err)
+		}
+		err = os.WriteFile(destPath, content, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", relPath, err)
+		}
+		changes = append(changes, TaskDirChange{Kind: kind, Path: relPath})
+	}
+
+	for _, relPath := range destFiles {
+		if inSrc[relPath] || !isInTaskDirManagedDir(relPath) {
+			continue
+		}
+		err = os.Remove(filepath.Join(destDir, filepath.FromSlash(relPath)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove %s: %w", relPath, err)
+		}
+		changes = append(changes, TaskDirChange{Kind: ChangeRemoved, Path: relPath})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+func isInTaskDirManagedDir(relPath string) bool {
+	for _, dir := range taskDirManagedDirs {
+		if strings.HasPrefix(relPath, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeProblemToml returns the new problem.toml with the keys that only the
+// old one has added by hand kept, or nil if the result is the same as the
+// old one. Only keys outside of problemTomlManagedKeys count as added by
+// hand.
+func mergeProblemToml(oldContent, newContent []byte) ([]byte, error) {
+	oldToml := map[string]interface{}{}
+	err := toml.Unmarshal(oldContent, &oldToml)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse existing problem.toml: %w", err)
+	}
+	newToml := map[string]interface{}{}
+	err = toml.Unmarshal(newContent, &newToml)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse new problem.toml: %w", err)
+	}
+
+	oldKept := dropManagedTomlKeys(oldToml, problemTomlManagedKeys)
+	merged, handAdded := mergeTomlTables(oldKept, newToml)
+	if reflect.DeepEqual(merged, oldToml) {
+		return nil, nil
+	}
+	if !handAdded {
+		// keep the layout fstaskparser writes
+		return newContent, nil
+	}
+
+	buf := bytes.Buffer{}
+	err = toml.NewEncoder(&buf).SetIndentTables(true).Encode(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode problem.toml: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// dropManagedTomlKeys returns the keys of the table that are not managed,
+// descending into managed tables for keys added to them by hand.
+func dropManagedTomlKeys(table map[string]interface{}, managed map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+	for key, value := range table {
+		managedKey, ok := managed[key]
+		if !ok {
+			res[key] = value
+			continue
+		}
+		managedSubtable, managedOk := managedKey.(map[string]interface{})
+		subtable, subtableOk := value.(map[string]interface{})
+		if !managedOk || !subtableOk {
+			continue
+		}
+		if kept := dropManagedTomlKeys(subtable, managedSubtable); len(kept) > 0 {
+			res[key] = kept
+		}
+	}
+	return res
+}
+
+// mergeTomlTables copies the new table and adds the keys that only the old
+// table has. It reports whether there were any such keys.
+func mergeTomlTables(oldTable, newTable map[string]interface{}) (map[string]interface{}, bool) {
+	res := map[string]interface{}{}
+	handAdded := false
+	for key, newValue := range newTable {
+		res[key] = newValue
+		oldSubtable, oldOk := oldTable[key].(map[string]interface{})
+		newSubtable, newOk := newValue.(map[string]interface{})
+		if oldOk && newOk {
+			subtable, added := mergeTomlTables(oldSubtable, newSubtable)
+			res[key] = subtable
+			handAdded = handAdded || added
+		}
+	}
+	for key, oldValue := range oldTable {
+		if _, ok := newTable[key]; !ok {
+			res[key] = oldValue
+			handAdded = true
+		}
+	}
+	return res, handAdded
+}
@@ -0,0 +1,116 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestUpdateTaskDir(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"problem.toml":    "task_name = 'Kp'\n\n[constraints]\n  cpu_time_seconds = 0.5\n",
+		"tests/001_a.in":  "1\n",
+		"tests/001_a.out": "2\n",
+		"tests/002_a.in":  "3\n",
+		"tests/002_a.out": "4\n",
+	})
+
+	dest := t.TempDir()
+	writeFiles(t, dest, map[string]string{
+		"problem.toml":     "task_name = 'Kp'\nowner = 'anna'\n\n[constraints]\n  cpu_time_seconds = 1\n  note = 'slow judge'\n",
+		"tests/001_a.in":   "1\n",
+		"tests/001_a.out":  "old\n",
+		"tests/003_a.in":   "5\n",
+		"validator/val.py": "import sys\n",
+		"solutions/kp.cpp": "int main() {}\n",
+	})
+
+	changes, err := internal.UpdateTaskDir(src, dest)
+	require.NoError(t, err)
+	assert.Equal(t, []internal.TaskDirChange{
+		{Kind: internal.ChangeChanged, Path: "problem.toml"},
+		{Kind: internal.ChangeChanged, Path: "tests/001_a.out"},
+		{Kind: internal.ChangeAdded, Path: "tests/002_a.in"},
+		{Kind: internal.ChangeAdded, Path: "tests/002_a.out"},
+		{Kind: internal.ChangeRemoved, Path: "tests/003_a.in"},
+		{Kind: internal.ChangeRemoved, Path: "validator/val.py"},
+	}, changes)
+
+	problemToml, err := os.ReadFile(filepath.Join(dest, "problem.toml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(problemToml), "owner = 'anna'")
+	assert.Contains(t, string(problemToml), "note = 'slow judge'")
+	assert.Contains(t, string(problemToml), "cpu_time_seconds = 0.5")
+
+	_, err = os.Stat(filepath.Join(dest, "solutions", "kp.cpp"))
+	assert.NoError(t, err)
+
+	changes, err = internal.UpdateTaskDir(src, dest)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestUpdateTaskDirReplacesOrigin(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"problem.toml": "task_name = 'Kp'\n\n[origin]\n  olympiad = 'LIO'\n",
+	})
+
+	dest := t.TempDir()
+	writeFiles(t, dest, map[string]string{
+		"problem.toml": "task_name = 'Kp'\nowner = 'anna'\n\n[origin]\n  olympiad = 'LIO'\n  year = 2023\n  stage = 'school'\n",
+	})
+
+	changes, err := internal.UpdateTaskDir(src, dest)
+	require.NoError(t, err)
+	assert.Equal(t, []internal.TaskDirChange{
+		{Kind: internal.ChangeChanged, Path: "problem.toml"},
+	}, changes)
+
+	problemToml, err := os.ReadFile(filepath.Join(dest, "problem.toml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(problemToml), "owner = 'anna'")
+	assert.Contains(t, string(problemToml), "olympiad = 'LIO'")
+	assert.NotContains(t, string(problemToml), "year")
+	assert.NotContains(t, string(problemToml), "stage")
+}
+
+func TestUpdateTaskDirDropsOmittedKeys(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"problem.toml": "task_name = 'Kp'\n\n[metadata]\n  task_authors = ['Anna']\n",
+	})
+
+	dest := t.TempDir()
+	writeFiles(t, dest, map[string]string{
+		"problem.toml": "task_name = 'Kp'\nillustration_image = 'kp.png'\n\n" +
+			"[metadata]\n  task_authors = ['Anna']\n  difficulty_1_to_5 = 4\n  reviewer = 'Jānis'\n\n" +
+			"[constraints]\n  memory_megabytes = 256\n",
+	})
+
+	changes, err := internal.UpdateTaskDir(src, dest)
+	require.NoError(t, err)
+	assert.Equal(t, []internal.TaskDirChange{
+		{Kind: internal.ChangeChanged, Path: "problem.toml"},
+	}, changes)
+
+	problemToml, err := os.ReadFile(filepath.Join(dest, "problem.toml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(problemToml), "reviewer = 'Jānis'")
+	assert.NotContains(t, string(problemToml), "illustration_image")
+	assert.NotContains(t, string(problemToml), "difficulty_1_to_5")
+	assert.NotContains(t, string(problemToml), "memory_megabytes")
+}
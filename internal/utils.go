@@ -4,9 +4,12 @@ import (
 	"archive/zip"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -20,9 +23,9 @@ func Unzip(src, dest string) error {
 	defer r.Close()
 
 	for _, f := range r.File {
-		fpath := filepath.Join(dest, f.Name)
-		if !strings.HasPrefix(fpath, filepath.Clean(dest)+string(os.PathSeparator)) {
-			return fmt.Errorf("illegal file path: %s", fpath)
+		fpath, err := archiveEntryPath(dest, f.Name)
+		if err != nil {
+			return err
 		}
 
 		if f.FileInfo().IsDir() {
@@ -56,6 +59,101 @@ func Unzip(src, dest string) error {
 	return nil
 }
 
+// archiveEntryPath returns where an archive entry is extracted to,
+// refusing entries that would end up outside of the destination.
+func archiveEntryPath(dest, name string) (string, error) {
+	fpath := filepath.Join(dest, name)
+	if fpath == filepath.Clean(dest) {
+		return fpath, nil // the root itself, as in "./" of tar archives
+	}
+	if !strings.HasPrefix(fpath, filepath.Clean(dest)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("illegal file path: %s", fpath)
+	}
+	return fpath, nil
+}
+
+// archiveModTime is the modification time of every entry in the archives
+// we write, so that the same content always produces the same bytes.
+// It is the earliest time the zip format can represent.
+var archiveModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// archiveFileMode is the mode of the file in the archives we write: 0755
+// for executables, such as build scripts, 0644 for everything else.
+func archiveFileMode(path string) (fs.FileMode, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if info.Mode()&0111 != 0 {
+		return 0755, nil
+	}
+	return 0644, nil
+}
+
+// ZipDir writes the files of a directory into a zip archive. Entries are
+// written in lexicographical order with a fixed modification time.
+func ZipDir(srcDir string, w io.Writer) error {
+	paths, err := listFilesSorted(srcDir)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	for _, relPath := range paths {
+		mode, err := archiveFileMode(filepath.Join(srcDir, filepath.FromSlash(relPath)))
+		if err != nil {
+			return err
+		}
+		header := &zip.FileHeader{
+			Name:     relPath,
+			Method:   zip.Deflate,
+			Modified: archiveModTime,
+		}
+		header.SetMode(mode)
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(filepath.Join(srcDir, filepath.FromSlash(relPath)))
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// listFilesSorted returns the slash-separated paths of all regular files
+// under the directory, relative to it, in lexicographical order.
+func listFilesSorted(dir string) ([]string, error) {
+	res := []string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		res = append(res, filepath.ToSlash(relPath))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
 // TaskYAML represents the structure of the task.yaml file.
 type TaskYAML struct {
 	Name          string  `yaml:"name"`
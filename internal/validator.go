@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validatorTimeout bounds a single run of a validator, so that a validator
+// stuck on a malformed input does not hang the whole check.
+const validatorTimeout = 30 * time.Second
+
+// validatorOutputLimit bounds how much of the output of a rejecting
+// validator ends up in the failure message.
+const validatorOutputLimit = 300
+
+// ValidatorArgs are the arguments a validator is run with for a test of
+// the group and subtask. testlib validators read the group with
+// registerValidation; other validators may read both on their own.
+func ValidatorArgs(group int, subtask int) []string {
+	return []string{"--group", strconv.Itoa(group), "--subtask", strconv.Itoa(subtask)}
+}
+
+// Validator is a compiled input validator, see CompileValidator.
+type Validator struct {
+	*Program
+}
+
+// CompileValidator compiles the validator source, see CompileProgram.
+// Close removes the compiled validator.
+func CompileValidator(ctx context.Context, files []SourceFile) (*Validator, error) {
+	p, err := CompileProgram(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{p}, nil
+}
+
+// Validate runs the validator on the input, see ValidatorArgs. It returns
+// what the validator printed if it rejects the input, "" if it accepts
+// it, and an error if the validator could not be run.
+func (v *Validator) Validate(ctx context.Context, input []byte, group int, subtask int) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, validatorTimeout)
+	defer cancel()
+
+	output, err := v.Run(ctx, ValidatorArgs(group, subtask), input)
+
+	exitErr := &exec.ExitError{}
+	switch {
+	case err == nil:
+		return "", nil
+	case ctx.Err() == context.DeadlineExceeded:
+		return fmt.Sprintf("timed out after %s", validatorTimeout), nil
+	case errors.As(err, &exitErr):
+		msg := strings.TrimSpace(string(exitErr.Stderr))
+		if msg == "" {
+			msg = strings.TrimSpace(string(output))
+		}
+		if len(msg) > validatorOutputLimit {
+			msg = msg[:validatorOutputLimit] + "..."
+		}
+		if msg == "" {
+			msg = exitErr.Error()
+		}
+		return msg, nil
+	}
+	return "", fmt.Errorf("failed to run validator: %w", err)
+}
+
+// StoreValidator writes the validator source and its headers to the
+// validator directory of a stored task. The fs task format has no place
+// for validators, readers of it ignore the directory.
+func StoreValidator(taskDirPath string, files []SourceFile) error {
+	validatorDir := filepath.Join(taskDirPath, "validator")
+	err := os.MkdirAll(validatorDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create validator directory: %w", err)
+	}
+	for _, f := range files {
+		err = os.WriteFile(filepath.Join(validatorDir, f.Filename), f.Content, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write validator: %w", err)
+		}
+	}
+	return nil
+}
+
+// ValidatorFailure is a test that the validator rejected.
+type ValidatorFailure struct {
+	// Test is the label of the test, such as 1a.
+	Test    string
+	Group   int
+	Subtask int
+	// Message is what the validator printed.
+	Message string
+}
+
+func (f ValidatorFailure) String() string {
+	return fmt.Sprintf("test %s (subtask %d): %s", f.Test, f.Subtask, f.Message)
+}
+
+// ValidateLioTests runs the validator on the input of every test. The
+// subtask of a test is looked up by its group in subtasks, groups missing
+// from it, such as the examples, are subtask 0. It returns the rejected
+// tests in the order given.
+func ValidateLioTests(ctx context.Context, v *Validator, tests []LioTest, subtasks map[int]int, timings *StageTimings) ([]ValidatorFailure, error) {
+	done := timings.Start("validate")
+	defer done()
+
+	failures := []ValidatorFailure{}
+	for _, t := range tests {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		subtask := subtasks[t.TestGroup]
+		msg, err := v.Validate(ctx, t.Input, t.TestGroup, subtask)
+		if err != nil {
+			return nil, fmt.Errorf("test %s: %w", lioTestLabel(t), err)
+		}
+		timings.logger().Debug("validated test", "test", lioTestLabel(t), "accepted", msg == "")
+		if msg == "" {
+			continue
+		}
+		failures = append(failures, ValidatorFailure{
+			Test:    lioTestLabel(t),
+			Group:   t.TestGroup,
+			Subtask: subtask,
+			Message: msg,
+		})
+	}
+	return failures, nil
+}
@@ -0,0 +1,63 @@
+package internal_test
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"testing/fstest"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validatorPy accepts a single number, at most 10 in subtask 1.
+const validatorPy = `import sys
+subtask = int(sys.argv[sys.argv.index("--subtask") + 1])
+n = int(sys.stdin.read())
+if subtask == 1 and n > 10:
+    sys.exit("n > 10 in subtask 1")
+`
+
+func TestFindValidator(t *testing.T) {
+	fsys := fstest.MapFS{
+		"task.yaml":          &fstest.MapFile{Data: []byte("tests_archive: ./testi/tests.zip\nvalidator: ./riki/validator.cpp\n")},
+		"riki/validator.cpp": &fstest.MapFile{Data: []byte("int main() {}\n")},
+		"riki/testlib.h":     &fstest.MapFile{Data: []byte("\n")},
+	}
+	imp, err := internal.GetImporter("lio2024")
+	require.NoError(t, err)
+
+	files, err := imp.(internal.ValidatorFinder).FindValidator(fsys)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "validator.cpp", files[0].Filename)
+	assert.Equal(t, "testlib.h", files[1].Filename)
+}
+
+func TestValidateLioTests(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+	ctx := context.Background()
+
+	v, err := internal.CompileValidator(ctx, []internal.SourceFile{{Filename: "validator.py", Content: []byte(validatorPy)}})
+	require.NoError(t, err)
+	defer v.Close()
+
+	tests := []internal.LioTest{
+		{TestGroup: 0, NoInTestGroup: 1, Input: []byte("50\n")},
+		{TestGroup: 1, NoInTestGroup: 1, Input: []byte("5\n")},
+		{TestGroup: 1, NoInTestGroup: 2, Input: []byte("11\n")},
+		{TestGroup: 2, NoInTestGroup: 1, Input: []byte("11\n")},
+	}
+	failures, err := internal.ValidateLioTests(ctx, v, tests, map[int]int{1: 1, 2: 2}, nil)
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	assert.Equal(t, "test 1b (subtask 1): n > 10 in subtask 1", failures[0].String())
+}
+
+func TestCompileValidatorUnsupported(t *testing.T) {
+	_, err := internal.CompileValidator(context.Background(), []internal.SourceFile{{Filename: "validator.pas"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+/*
+Package lioimport imports olympiad tasks into the programme.lv task format
+in-process, without running the lio-task-importer command.
+
+The typical use is to parse a task directory or archive, validate it and
+write it in a target format:
+
+	res, err := lioimport.Parse(ctx, "lio2024/3.kārta/kp", lioimport.ParseOptions{})
+	if err != nil {
+		return err
+	}
+	err = lioimport.Validate(res.Task, lioimport.ValidateOptions{})
+	if err != nil {
+		return err
+	}
+	err = lioimport.Convert(res, "out/kp_proglv", lioimport.ConvertOptions{})
+
+Tasks that are not on disk, such as an uploaded archive held in memory
+or fixtures in an embed.FS, are read with ParseArchive and ParseFS.
+Every format is read without touching disk except proglv, which is
+parsed from a temporary copy.
+
+Parsing stops between files once the context is cancelled. A
+ParseOptions.Progress callback receives ProgressEvent values as stages
+start and finish and as tests are read, e.g. to drive a progress bar.
+
+The lower-level readers of the LIO 2024 format, ParseLio2024Yaml,
+ReadLioTestsFromZip and their fs.FS variants, are exported as well.
+
+Errors can be told apart with errors.Is against ErrUnsupportedFormat,
+ErrFormatNotDetected, ErrCheckerNotSupported and ErrGradersNotSupported,
+and with errors.As against *ParseError and *ValidationError. Problems
+with the task itself are *ImportError values with a stable ErrorCode
+and, where known, the file and line they are in; errors.Is(err,
+ErrMissingFile) and the like match them by code, also among the many
+problems of a ValidationError.
+Functions of this package keep their signatures, error types and error
+codes within a major version.
+*/
+package lioimport
@@ -0,0 +1,107 @@
+package lioimport
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/programme-lv/lio-task-importer/internal"
+)
+
+var (
+	// ErrUnsupportedFormat is returned when a source or target format is
+	// not known, see Formats and Targets.
+	ErrUnsupportedFormat = internal.ErrUnsupportedFormat
+	// ErrFormatNotDetected is returned by Parse when the format is not
+	// given and none or more than one format matches the source.
+	ErrFormatNotDetected = internal.ErrFormatNotDetected
+	// ErrCheckerNotSupported is returned by Convert when the task has a
+	// checker and the target format cannot hold or run it.
+	ErrCheckerNotSupported = internal.ErrCheckerNotSupported
+	// ErrGradersNotSupported is returned by Convert when the task has
+	// graders and the target format cannot hold them.
+	ErrGradersNotSupported = internal.ErrGradersNotSupported
+)
+
+type (
+	// ErrorCode identifies a kind of problem with a task. Codes are
+	// stable, tools may match on them.
+	ErrorCode = internal.ErrorCode
+	// ImportError is a problem with the task being imported, with the
+	// file and line it is in when known. It is found with errors.As in
+	// the chain of ParseError and in ValidationError.
+	ImportError = internal.ImportError
+	// ErrorList holds errors reported at once.
+	ErrorList = internal.ErrorList
+)
+
+// Error codes, see ImportError.
+const (
+	CodeMissingFile        = internal.CodeMissingFile
+	CodeBadTestFilename    = internal.CodeBadTestFilename
+	CodeGroupMismatch      = internal.CodeGroupMismatch
+	CodeLimitExceeded      = internal.CodeLimitExceeded
+	CodeInvalidSyntax      = internal.CodeInvalidSyntax
+	CodeEmptyAnswer        = internal.CodeEmptyAnswer
+	CodeConflictingAnswers = internal.CodeConflictingAnswers
+	CodeUnknownTag         = internal.CodeUnknownTag
+)
+
+// Sentinels to match the code of an *ImportError with errors.Is, e.g.
+// errors.Is(err, ErrMissingFile).
+var (
+	ErrMissingFile        = internal.ErrMissingFile
+	ErrBadTestFilename    = internal.ErrBadTestFilename
+	ErrGroupMismatch      = internal.ErrGroupMismatch
+	ErrLimitExceeded      = internal.ErrLimitExceeded
+	ErrInvalidSyntax      = internal.ErrInvalidSyntax
+	ErrEmptyAnswer        = internal.ErrEmptyAnswer
+	ErrConflictingAnswers = internal.ErrConflictingAnswers
+	ErrUnknownTag         = internal.ErrUnknownTag
+)
+
+// ParseError is returned by Parse when the source is recognised but
+// cannot be read.
+type ParseError struct {
+	// Source is the path passed to Parse.
+	Source string
+	// Format is the source format the task was parsed as.
+	Format string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("failed to parse %s as %s: %v", e.Source, e.Format, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError lists everything wrong with a task that was otherwise
+// read successfully.
+type ValidationError struct {
+	// Problems are human-readable descriptions, one per problem.
+	Problems []string
+	// Errors are the problems as errors, mostly *ImportError, in the
+	// same order as Problems.
+	Errors []error
+}
+
+func newValidationError(errs []error) *ValidationError {
+	res := &ValidationError{Errors: errs}
+	for _, err := range errs {
+		res.Problems = append(res.Problems, err.Error())
+	}
+	return res
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid task: " + strings.Join(e.Problems, "; ")
+}
+
+// Unwrap lets errors.Is and errors.As look through all problems, e.g.
+// errors.Is(err, ErrGroupMismatch).
+func (e *ValidationError) Unwrap() []error {
+	return e.Errors
+}
@@ -0,0 +1,445 @@
+package lioimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"github.com/programme-lv/lio-task-importer/internal"
+)
+
+// ProglvTarget is the target format of Convert that writes a programme.lv
+// task directory.
+const ProglvTarget = "proglv"
+
+type (
+	// Origin describes the competition a task was used in.
+	Origin = internal.Origin
+	// SourceFile is a source file of a checker or another task program.
+	SourceFile = internal.SourceFile
+	// LioTest is a test as read from a LIO tests archive.
+	LioTest = internal.LioTest
+	// Lio2024Yaml is the content of a LIO 2024 task.yaml.
+	Lio2024Yaml = internal.ParsedLio2024Yaml
+	// Lio2024YamlTestGroup is a test group of a LIO 2024 task.yaml.
+	Lio2024YamlTestGroup = internal.ParsedLio2024YamlTestGroup
+	// ProgressEvent tells how far Parse has got, see ParseOptions.Progress.
+	ProgressEvent = internal.ProgressEvent
+)
+
+// Kinds of checkers, see Result.CheckerKind.
+const (
+	CheckerTestlib = internal.CheckerTestlib
+	CheckerCms     = internal.CheckerCms
+	CheckerKattis  = internal.CheckerKattis
+)
+
+// Kinds of ProgressEvent.
+const (
+	ProgressStageStarted  = internal.ProgressStageStarted
+	ProgressStageFinished = internal.ProgressStageFinished
+	ProgressTestsRead     = internal.ProgressTestsRead
+)
+
+// Formats returns the names of the source formats Parse reads.
+func Formats() []string {
+	res := []string{}
+	for _, imp := range internal.Importers() {
+		res = append(res, imp.Name())
+	}
+	return res
+}
+
+// Targets returns the names of the target formats Convert writes.
+func Targets() []string {
+	res := []string{ProglvTarget}
+	for _, exp := range internal.Exporters() {
+		res = append(res, exp.Name())
+	}
+	return res
+}
+
+// ParseOptions configures Parse, ParseFS and ParseArchive.
+type ParseOptions struct {
+	// Format is the source format, see Formats. Empty or "auto" detects
+	// it from the source.
+	Format string
+	// Origin takes precedence over the origin found in olympiad.yaml and
+	// in the source path.
+	Origin Origin
+	// Progress, if set, is called as the stages of parsing start and
+	// finish and as tests are read. It is called from the goroutine
+	// that parses.
+	Progress func(ProgressEvent)
+	// Logger, if set, receives debug messages about the stages of
+	// parsing. Nothing is logged otherwise; problems with the task are
+	// returned as errors or Result.Warnings either way.
+	Logger *slog.Logger
+	// NormalizeTests is how the tests of the LIO formats are normalised:
+	// NormalizeOff (the default), NormalizeFix or NormalizeStrict. The
+	// tests changed, or that would be changed, are listed in
+	// Result.Warnings. Other formats ignore it.
+	NormalizeTests string
+	// GenerateTests makes the tests of an lio2024 task by running the
+	// generator_plan and solution named in task.yaml, which needs g++,
+	// gcc or python3. If the task ships a test archive too, the tests that
+	// differ from it are listed in Result.Warnings. Legacy LIO tasks fail
+	// to parse with it, other formats ignore it.
+	GenerateTests bool
+}
+
+// Test normalisation modes, see ParseOptions.NormalizeTests. Normalising
+// removes UTF-8 byte order marks, re-encodes Windows-1257 text to UTF-8,
+// replaces CRLF line endings with LF and adds missing trailing newlines.
+const (
+	NormalizeOff    = internal.NormalizeOff
+	NormalizeFix    = internal.NormalizeFix
+	NormalizeStrict = internal.NormalizeStrict
+)
+
+// Result is a parsed task along with what the task format cannot hold.
+type Result struct {
+	Task *fstaskparser.Task
+	// Format is the source format the task was parsed as.
+	Format string
+	// ShortName is the short identifier of the task, such as "kp".
+	ShortName string
+	Origin    Origin
+	// Checker is the checker source followed by its headers, nil if the
+	// task has no checker.
+	Checker []SourceFile
+	// CheckerKind is how the checker is run, one of CheckerTestlib,
+	// CheckerCms and CheckerKattis. Targets only take checkers of the
+	// kind they run.
+	CheckerKind string
+	// Validator is the input validator source followed by its headers,
+	// nil if the task has no validator.
+	Validator []SourceFile
+	// Graders are the grader sources followed by their headers, nil if
+	// the task has no graders.
+	Graders []SourceFile
+	// Warnings describe the guesses made to fill in information missing
+	// from the source.
+	Warnings []string
+}
+
+// Parse reads the task at sourcePath, a task directory or a .zip/.tar.gz
+// archive of one. The olympiad.yaml files of the parent directories and
+// the names of the directories are used to tell the origin of the task.
+//
+// The context is checked between files, a cancelled context makes Parse
+// return the context's error.
+func Parse(ctx context.Context, sourcePath string, opts ParseOptions) (*Result, error) {
+	var fsys fs.FS = os.DirFS(sourcePath)
+	name := filepath.Base(sourcePath)
+	if format := internal.ArchiveFormatFromPath(sourcePath); format != "" {
+		content, err := os.ReadFile(sourcePath)
+		if err != nil {
+			return nil, &ParseError{Source: sourcePath, Format: opts.Format, Err: err}
+		}
+		fsys, name, err = archiveTaskFS(ctx, content, format, name)
+		if err != nil {
+			return nil, &ParseError{Source: sourcePath, Format: opts.Format, Err: err}
+		}
+	}
+
+	res, err := parseFS(ctx, fsys, name, sourcePath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Origin, err = internal.ResolveOrigin(opts.Origin, sourcePath, res.Task)
+	if err != nil {
+		return nil, &ParseError{Source: sourcePath, Format: res.Format, Err: err}
+	}
+	res.Task.SetOriginOlympiad(res.Origin.Olympiad)
+	res.ShortName = internal.TaskShortName(sourcePath)
+	return res, nil
+}
+
+// ParseFS reads the task directory at the root of fsys, such as an
+// embed.FS or an fs.Sub of one. name stands in for the name of the task
+// directory. Only the olympiad.yaml at the root of fsys is used to tell
+// the origin of the task.
+//
+// Every format but proglv is read from fsys directly, proglv tasks are
+// parsed from a copy in a temporary directory. The context is checked as
+// in Parse.
+func ParseFS(ctx context.Context, fsys fs.FS, name string, opts ParseOptions) (*Result, error) {
+	res, err := parseFS(ctx, fsys, name, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return resolveOriginFS(res, fsys, name, opts)
+}
+
+// ParseArchive reads an archived task held in memory, such as an uploaded
+// file. The archive format is told from the extension of archiveName.
+// Archives holding the task in a single top-level directory are supported
+// as well as ones with the task at the root. The context is checked as in
+// Parse.
+func ParseArchive(ctx context.Context, content []byte, archiveName string, opts ParseOptions) (*Result, error) {
+	format := internal.ArchiveFormatFromPath(archiveName)
+	if format == "" {
+		return nil, &ParseError{Source: archiveName, Format: opts.Format,
+			Err: fmt.Errorf("%s is not a .zip, .tar.gz or .tgz archive", archiveName)}
+	}
+	fsys, name, err := archiveTaskFS(ctx, content, format, path.Base(archiveName))
+	if err != nil {
+		return nil, &ParseError{Source: archiveName, Format: opts.Format, Err: err}
+	}
+
+	res, err := parseFS(ctx, fsys, name, archiveName, opts)
+	if err != nil {
+		return nil, err
+	}
+	return resolveOriginFS(res, fsys, name, opts)
+}
+
+// archiveTaskFS returns the task directory of the archive and its name.
+func archiveTaskFS(ctx context.Context, content []byte, format string, archiveName string) (fs.FS, string, error) {
+	archive, err := internal.ArchiveFS(ctx, content, format)
+	if err != nil {
+		return nil, "", err
+	}
+	fsys, dirName, err := internal.TaskRootFS(archive)
+	if err != nil {
+		return nil, "", err
+	}
+	if dirName == "" {
+		dirName = internal.TrimArchiveExtension(archiveName)
+	}
+	return fsys, dirName, nil
+}
+
+func parseFS(ctx context.Context, fsys fs.FS, name string, source string, opts ParseOptions) (*Result, error) {
+	format := opts.Format
+	var importer internal.Importer
+	var err error
+	if format == "" || format == "auto" {
+		importer, err = internal.DetectImporterFS(fsys, source)
+	} else {
+		importer, err = internal.GetImporter(format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	timings := &internal.StageTimings{Progress: opts.Progress, Logger: opts.Logger}
+	parseOpts := internal.ParseOptions{NormalizeTests: opts.NormalizeTests, GenerateTests: opts.GenerateTests}
+	task, warnings, err := internal.ParseTaskFS(ctx, importer, fsys, name, parseOpts, timings)
+	if err != nil {
+		return nil, &ParseError{Source: source, Format: importer.Name(), Err: err}
+	}
+
+	var checker []SourceFile
+	var checkerKind string
+	if finder, ok := importer.(internal.CheckerFinder); ok {
+		checker, err = finder.FindChecker(fsys)
+		if err != nil {
+			return nil, &ParseError{Source: source, Format: importer.Name(), Err: err}
+		}
+		checkerKind = finder.CheckerKind()
+	}
+
+	var validator []SourceFile
+	if finder, ok := importer.(internal.ValidatorFinder); ok {
+		validator, err = finder.FindValidator(fsys)
+		if err != nil {
+			return nil, &ParseError{Source: source, Format: importer.Name(), Err: err}
+		}
+	}
+
+	var graders []SourceFile
+	if finder, ok := importer.(internal.GraderFinder); ok {
+		graders, err = finder.FindGraders(fsys)
+		if err != nil {
+			return nil, &ParseError{Source: source, Format: importer.Name(), Err: err}
+		}
+	}
+
+	if warnings == nil {
+		warnings = []string{}
+	}
+	return &Result{
+		Task:        task,
+		Format:      importer.Name(),
+		Checker:     checker,
+		CheckerKind: checkerKind,
+		Validator:   validator,
+		Graders:     graders,
+		Warnings:    warnings,
+	}, nil
+}
+
+func resolveOriginFS(res *Result, fsys fs.FS, name string, opts ParseOptions) (*Result, error) {
+	olympiadConfig, err := internal.ReadOlympiadConfigFS(fsys)
+	if err != nil {
+		return nil, &ParseError{Source: name, Format: res.Format, Err: err}
+	}
+	res.Origin = opts.Origin.
+		Merge(olympiadConfig).
+		Merge(Origin{Olympiad: res.Task.GetOriginOlympiad()})
+	res.Task.SetOriginOlympiad(res.Origin.Olympiad)
+	res.ShortName = internal.TaskShortName(name)
+	return res, nil
+}
+
+// ConvertOptions configures Convert.
+type ConvertOptions struct {
+	// Target is the target format, see Targets. Empty means ProglvTarget.
+	Target string
+}
+
+// Convert writes the parsed task to destPath in the target format.
+// destPath must not exist yet. Targets writing a single file, such as
+// domjudge, expect destPath to carry the extension, e.g. "kp.zip".
+func Convert(res *Result, destPath string, opts ConvertOptions) error {
+	target := opts.Target
+	if target == "" {
+		target = ProglvTarget
+	}
+
+	if target == ProglvTarget {
+		if res.Checker != nil {
+			return fmt.Errorf("%w: %s cannot hold %s", ErrCheckerNotSupported, target, res.Checker[0].Filename)
+		}
+		err := res.Task.Store(destPath)
+		if err != nil {
+			return fmt.Errorf("failed to store task: %w", err)
+		}
+		err = internal.WriteOriginToProblemToml(destPath, res.Origin)
+		if err != nil {
+			return fmt.Errorf("failed to store task origin: %w", err)
+		}
+		if res.Validator != nil {
+			err = internal.StoreValidator(destPath, res.Validator)
+			if err != nil {
+				return fmt.Errorf("failed to store validator: %w", err)
+			}
+		}
+		if res.Graders != nil {
+			err = internal.StoreGraders(destPath, res.Graders)
+			if err != nil {
+				return fmt.Errorf("failed to store graders: %w", err)
+			}
+		}
+		return nil
+	}
+
+	exporter, err := internal.GetExporter(target)
+	if err != nil {
+		return err
+	}
+	err = exporter.Export(res.Task, destPath, internal.ExportOptions{
+		ShortName:   res.ShortName,
+		Checker:     res.Checker,
+		CheckerKind: res.CheckerKind,
+		Validator:   res.Validator,
+		Graders:     res.Graders,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export task to %s: %w", target, err)
+	}
+	return nil
+}
+
+// ValidateOptions configures Validate.
+type ValidateOptions struct {
+	// TagVocabulary lists the allowed tags, nil allows any tag.
+	TagVocabulary []string
+}
+
+// Validate checks the metadata of the task. All problems found are
+// reported at once in a *ValidationError.
+func Validate(task *fstaskparser.Task, opts ValidateOptions) error {
+	var vocabulary internal.TagVocabulary
+	if opts.TagVocabulary != nil {
+		vocabulary = internal.TagVocabulary{}
+		for _, tag := range opts.TagVocabulary {
+			vocabulary[tag] = true
+		}
+	}
+
+	metadata := internal.TaskMetadata{
+		Tags:       task.GetProblemTags(),
+		Difficulty: task.GetDifficultyOneToFive(),
+	}
+	err := metadata.Validate(vocabulary)
+	var errs internal.ErrorList
+	if errors.As(err, &errs) {
+		return newValidationError(errs)
+	}
+	return err
+}
+
+// ValidateLio2024TaskDir checks that the task.yaml of a LIO 2024 task
+// directory agrees with its tests: every group has tests, every test
+// belongs to a group, subtask points add up and no answer is empty.
+func ValidateLio2024TaskDir(dirPath string) error {
+	return validateLio2024Task(os.DirFS(dirPath), dirPath)
+}
+
+// ValidateLio2024TaskFS is ValidateLio2024TaskDir for the task directory
+// at the root of fsys.
+func ValidateLio2024TaskFS(fsys fs.FS) error {
+	return validateLio2024Task(fsys, ".")
+}
+
+func validateLio2024Task(fsys fs.FS, source string) error {
+	parsedYaml, tests, err := internal.ReadLio2024TaskSourcesFS(fsys)
+	if err != nil {
+		return &ParseError{Source: source, Format: "lio2024", Err: err}
+	}
+
+	errs := internal.CheckLio2024Task(parsedYaml, tests)
+	if len(errs) > 0 {
+		return newValidationError(errs)
+	}
+	return nil
+}
+
+// ParseLio2024TaskDir reads a LIO 2024 task directory. Unlike Parse, it
+// does not resolve the origin, and it fails with ErrCheckerNotSupported
+// for a task with a checker, which only Parse returns.
+func ParseLio2024TaskDir(dirPath string) (*fstaskparser.Task, error) {
+	task, err := internal.ParseLio2024TaskDir(dirPath)
+	if err != nil {
+		return nil, &ParseError{Source: dirPath, Format: "lio2024", Err: err}
+	}
+	return task, nil
+}
+
+// ParseLio2024Yaml parses the content of a LIO 2024 task.yaml.
+func ParseLio2024Yaml(content []byte) (Lio2024Yaml, error) {
+	return internal.ParseLio2024Yaml(content)
+}
+
+// ReadLioTestsFromZip reads the tests of a LIO tests.zip.
+func ReadLioTestsFromZip(zipPath string) ([]LioTest, error) {
+	return internal.ReadLioTestsFromZip(zipPath)
+}
+
+// ReadLioTestsFromArchive reads the tests of a LIO tests archive, either
+// a .zip or a .tar.gz.
+func ReadLioTestsFromArchive(archivePath string) ([]LioTest, error) {
+	return internal.ReadLioTestsFromArchive(archivePath)
+}
+
+// ReadLioTestsFromArchiveFS is ReadLioTestsFromArchive for the named
+// archive in fsys. The archive is read into memory.
+func ReadLioTestsFromArchiveFS(fsys fs.FS, name string) ([]LioTest, error) {
+	return internal.ReadLioTestsFromArchiveFS(fsys, name)
+}
+
+// ReadLioTestsFromFS reads loose LIO test files, such as kp.i01a and
+// kp.o01a, from the root of fsys.
+func ReadLioTestsFromFS(fsys fs.FS) ([]LioTest, error) {
+	return internal.ReadLioTestsFromFS(fsys)
+}
@@ -0,0 +1,217 @@
+package lioimport_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/programme-lv/fs-task-format-parser/pkg/fstaskparser"
+	"github.com/programme-lv/lio-task-importer/pkg/lioimport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLio2024Task writes a small LIO 2024 task directory named kp.
+func writeLio2024Task(t *testing.T, checker []lioimport.SourceFile) string {
+	task, err := fstaskparser.NewTask("Kvadrātveida putekļsūcējs")
+	require.NoError(t, err)
+	task.SetCPUTimeLimitInSeconds(0.5)
+	task.SetMemoryLimitInMegabytes(256)
+	task.SetProblemTags([]string{"greedy"})
+	task.AddExample([]byte("1 2\n"), []byte("3\n"))
+	a := task.AddTest([]byte("2 2\n"), []byte("4\n"))
+	b := task.AddTest([]byte("2 3\n"), []byte("5\n"))
+	require.NoError(t, task.AddTestGroupWithID(1, 100, true, []int{a, b}, 1))
+	require.NoError(t, task.AddPDFStatement("lv", []byte("%PDF")))
+
+	dir := filepath.Join(t.TempDir(), "kp")
+	err = lioimport.Convert(&lioimport.Result{Task: task, ShortName: "kp", Checker: checker, CheckerKind: lioimport.CheckerTestlib},
+		dir, lioimport.ConvertOptions{Target: "lio2024"})
+	require.NoError(t, err)
+	return dir
+}
+
+func TestParseAndConvert(t *testing.T) {
+	dir := writeLio2024Task(t, nil)
+
+	res, err := lioimport.Parse(context.Background(), dir, lioimport.ParseOptions{Origin: lioimport.Origin{Year: 2024}})
+	require.NoError(t, err)
+	assert.Equal(t, "lio2024", res.Format)
+	assert.Equal(t, "kp", res.ShortName)
+	assert.Equal(t, 2024, res.Origin.Year)
+	assert.Nil(t, res.Checker)
+	assert.Equal(t, "Kvadrātveida putekļsūcējs", res.Task.GetTaskName())
+	assert.Len(t, res.Task.GetTestsSortedByID(), 2)
+
+	require.NoError(t, lioimport.Validate(res.Task, lioimport.ValidateOptions{TagVocabulary: []string{"greedy"}}))
+	require.NoError(t, lioimport.ValidateLio2024TaskDir(dir))
+
+	dest := filepath.Join(t.TempDir(), "kp_proglv")
+	require.NoError(t, lioimport.Convert(res, dest, lioimport.ConvertOptions{}))
+	problemToml, err := os.ReadFile(filepath.Join(dest, "problem.toml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(problemToml), "year = 2024")
+}
+
+// zipDir zips the directory with its files placed under prefix.
+func zipDir(t *testing.T, dir string, prefix string) []byte {
+	buf := bytes.Buffer{}
+	zw := zip.NewWriter(&buf)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(prefix + filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	})
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestParseArchive(t *testing.T) {
+	dir := writeLio2024Task(t, nil)
+
+	for _, prefix := range []string{"", "kp/"} {
+		res, err := lioimport.ParseArchive(context.Background(), zipDir(t, dir, prefix), "uploads/kp.zip", lioimport.ParseOptions{})
+		require.NoError(t, err, prefix)
+		assert.Equal(t, "lio2024", res.Format)
+		assert.Equal(t, "kp", res.ShortName)
+		assert.Len(t, res.Task.GetTestsSortedByID(), 2)
+	}
+
+	_, err := lioimport.ParseArchive(context.Background(), []byte("x"), "kp.rar", lioimport.ParseOptions{})
+	parseErr := &lioimport.ParseError{}
+	assert.ErrorAs(t, err, &parseErr)
+}
+
+func TestParseFS(t *testing.T) {
+	dir := writeLio2024Task(t, nil)
+
+	fsys := fstest.MapFS{
+		"olympiad.yaml": &fstest.MapFile{Data: []byte("olympiad: LIO\nyear: 2024\n")},
+	}
+	err := fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(os.DirFS(dir), path)
+		fsys[path] = &fstest.MapFile{Data: content}
+		return err
+	})
+	require.NoError(t, err)
+
+	res, err := lioimport.ParseFS(context.Background(), fsys, "kp", lioimport.ParseOptions{Format: "lio2024"})
+	require.NoError(t, err)
+	assert.Equal(t, "kp", res.ShortName)
+	assert.Equal(t, lioimport.Origin{Olympiad: "LIO", Year: 2024}, res.Origin)
+	assert.Len(t, res.Task.GetExamples(), 1)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := lioimport.Parse(context.Background(), t.TempDir(), lioimport.ParseOptions{Format: "nope"})
+	assert.ErrorIs(t, err, lioimport.ErrUnsupportedFormat)
+
+	_, err = lioimport.Parse(context.Background(), t.TempDir(), lioimport.ParseOptions{})
+	assert.ErrorIs(t, err, lioimport.ErrFormatNotDetected)
+
+	dir := t.TempDir()
+	_, err = lioimport.Parse(context.Background(), dir, lioimport.ParseOptions{Format: "lio2024"})
+	parseErr := &lioimport.ParseError{}
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, dir, parseErr.Source)
+	assert.Equal(t, "lio2024", parseErr.Format)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestConvertChecker(t *testing.T) {
+	dir := writeLio2024Task(t, []lioimport.SourceFile{{Filename: "checker.cpp", Content: []byte("int main() {}\n")}})
+
+	res, err := lioimport.Parse(context.Background(), dir, lioimport.ParseOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, res.Checker)
+	assert.Equal(t, lioimport.CheckerTestlib, res.CheckerKind)
+
+	err = lioimport.Convert(res, filepath.Join(t.TempDir(), "kp_proglv"), lioimport.ConvertOptions{})
+	assert.ErrorIs(t, err, lioimport.ErrCheckerNotSupported)
+
+	err = lioimport.Convert(res, filepath.Join(t.TempDir(), "kp.zip"), lioimport.ConvertOptions{Target: "nope"})
+	assert.ErrorIs(t, err, lioimport.ErrUnsupportedFormat)
+}
+
+func TestValidate(t *testing.T) {
+	task, err := fstaskparser.NewTask("Kp")
+	require.NoError(t, err)
+	task.SetProblemTags([]string{"greedy", "dp"})
+	task.SetDifficultyOneToFive(7)
+
+	err = lioimport.Validate(task, lioimport.ValidateOptions{TagVocabulary: []string{"greedy"}})
+	validationErr := &lioimport.ValidationError{}
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		"difficulty must be between 1 and 5, got 7",
+		"tags not in vocabulary: dp",
+	}, validationErr.Problems)
+	assert.ErrorIs(t, err, lioimport.ErrLimitExceeded)
+	assert.ErrorIs(t, err, lioimport.ErrUnknownTag)
+}
+
+func TestValidateLio2024TaskFS(t *testing.T) {
+	dir := writeLio2024Task(t, nil)
+	taskYaml, err := os.ReadFile(filepath.Join(dir, "task.yaml"))
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{}
+	require.NoError(t, fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(os.DirFS(dir), path)
+		fsys[path] = &fstest.MapFile{Data: content}
+		return err
+	}))
+	fsys["task.yaml"] = &fstest.MapFile{Data: append(taskYaml, "subtask_points: [0, 90]\n"...)}
+
+	err = lioimport.ValidateLio2024TaskFS(fsys)
+	assert.ErrorIs(t, err, lioimport.ErrGroupMismatch)
+	importErr := &lioimport.ImportError{}
+	require.ErrorAs(t, err, &importErr)
+	assert.Equal(t, lioimport.CodeGroupMismatch, importErr.Code)
+	assert.Equal(t, "task.yaml", importErr.File)
+}
+
+func TestConvertValidator(t *testing.T) {
+	res, err := lioimport.Parse(context.Background(), writeLio2024Task(t, nil), lioimport.ParseOptions{})
+	require.NoError(t, err)
+	res.Validator = []lioimport.SourceFile{{Filename: "validator.py", Content: []byte("import sys\n")}}
+
+	dir := filepath.Join(t.TempDir(), "kp")
+	require.NoError(t, lioimport.Convert(res, dir, lioimport.ConvertOptions{Target: "lio2024"}))
+	res, err = lioimport.Parse(context.Background(), dir, lioimport.ParseOptions{})
+	require.NoError(t, err)
+	require.Len(t, res.Validator, 1)
+	assert.Equal(t, "validator.py", res.Validator[0].Filename)
+
+	dest := filepath.Join(t.TempDir(), "kp_proglv")
+	require.NoError(t, lioimport.Convert(res, dest, lioimport.ConvertOptions{}))
+	content, err := os.ReadFile(filepath.Join(dest, "validator", "validator.py"))
+	require.NoError(t, err)
+	assert.Equal(t, "import sys\n", string(content))
+}